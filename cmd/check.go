@@ -0,0 +1,274 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/difyz9/Link2COS/config"
+	"github.com/difyz9/Link2COS/internal/console"
+	"github.com/difyz9/Link2COS/internal/constants"
+	"github.com/difyz9/Link2COS/internal/download"
+	"github.com/difyz9/Link2COS/internal/events"
+	"github.com/difyz9/Link2COS/internal/tracker"
+	"github.com/difyz9/Link2COS/internal/util"
+	"github.com/spf13/cobra"
+)
+
+var (
+	checkInputFile   string
+	checkConfigFile  string
+	checkConcurrency int
+)
+
+// checkCmd represents the check command
+var checkCmd = &cobra.Command{
+	Use:   "check",
+	Short: "检查链接是否可用，不下载",
+	Long: `并发检查输入文件中的每个链接：状态码、重定向目标、文件大小和是否支持 Range，
+标出不存在（404）、需要认证（401/403）以及大小与下载记录不同的链接。
+请求经过配置的代理并携带 sources 中的认证，适合在长时间的 sync 之前发现失效链接。`,
+	RunE: runCheck,
+}
+
+func init() {
+	rootCmd.AddCommand(checkCmd)
+	checkCmd.Flags().StringVarP(&checkInputFile, "input", "i", "", "输入文件路径（必填）")
+	checkCmd.Flags().StringVarP(&checkConfigFile, "config", "c", constants.DefaultConfigFile, "配置文件路径（默认: config.yaml）")
+	checkCmd.Flags().IntVarP(&checkConcurrency, "concurrency", "j", 8, "同时检查的链接数")
+	checkCmd.MarkFlagRequired("input")
+}
+
+// 检查结果的分类
+const (
+	checkOK       = "ok"
+	checkNotFound = "not_found"
+	checkAuth     = "auth_required"
+	checkHTTP     = "http_error"
+	checkNetwork  = "network_error"
+)
+
+func runCheck(cmd *cobra.Command, args []string) error {
+	if checkConcurrency < 1 {
+		return configError(fmt.Errorf("--concurrency 必须大于 0"))
+	}
+
+	// 检查只访问下载源，不需要COS配置
+	cfg, err := config.LoadConfig(checkConfigFile, loadOptions(config.ForDownload))
+	if err != nil {
+		return configError(fmt.Errorf("加载配置失败: %w", err))
+	}
+
+	// 探测请求很小，不经过限速器
+	httpClient, err := download.CreateHTTPClient(cfg, nil)
+	if err != nil {
+		return configError(err)
+	}
+
+	linkTracker, err := tracker.NewLinkTracker(constants.DownloadedLinksFile)
+	if err != nil {
+		return fmt.Errorf("初始化链接跟踪器失败: %w", err)
+	}
+
+	links, err := util.ReadLinksFromFile(checkInputFile)
+	if err != nil {
+		return configError(fmt.Errorf("读取输入文件失败: %w", err))
+	}
+	console.Printf("共找到 %d 个链接，并发 %d\n", len(links), checkConcurrency)
+
+	rc := newRunContext()
+	defer rc.release()
+
+	// 并发检查，按输入顺序输出
+	downloader := download.NewDownloader(httpClient, "")
+	results := make([]chan *download.CheckResult, len(links))
+	for i := range results {
+		results[i] = make(chan *download.CheckResult, 1)
+	}
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < checkConcurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] <- downloader.Check(rc.work, links[i])
+			}
+		}()
+	}
+	go func() {
+		defer close(jobs)
+		for i := range links {
+			select {
+			case jobs <- i:
+			case <-rc.stop.Done():
+				// 收到中断信号后不再开始新的检查
+				for ; i < len(links); i++ {
+					results[i] <- nil
+				}
+				return
+			}
+		}
+	}()
+
+	stats := &checkStats{total: len(links), byStatus: make(map[string]int)}
+	for i, link := range links {
+		result := <-results[i]
+		if result == nil {
+			stats.notStarted++
+			continue
+		}
+		console.Printf("[%d/%d] %s\n", i+1, len(links), link)
+		entry, tracked := linkTracker.Get(link)
+		stats.add(result, entry, tracked)
+	}
+	wg.Wait()
+
+	return stats.finish()
+}
+
+// checkStats check 命令的汇总
+type checkStats struct {
+	total       int
+	byStatus    map[string]int
+	sizeChanged int
+	noRange     int
+	unknownSize int
+	bytes       int64
+	notStarted  int
+}
+
+// add 输出一个链接的检查结果
+func (s *checkStats) add(r *download.CheckResult, entry tracker.Entry, tracked bool) {
+	status := classifyCheck(r)
+	s.byStatus[status]++
+
+	fields := events.Fields{
+		"link":   r.Link,
+		"status": status,
+		"method": r.Method,
+	}
+	if r.StatusCode != 0 {
+		fields["status_code"] = r.StatusCode
+	}
+	if r.FinalURL != "" {
+		fields["final_url"] = r.FinalURL
+		fields["redirects"] = r.Redirects
+	}
+
+	switch status {
+	case checkOK:
+		fields["size"] = events.Size(r.Size)
+		fields["accept_ranges"] = r.AcceptRanges
+
+		size := "大小未知"
+		if r.Size >= 0 {
+			s.bytes += r.Size
+			size = fmt.Sprintf("%.2f MB", float64(r.Size)/(1024*1024))
+		} else {
+			s.unknownSize++
+		}
+		ranges := "支持 Range"
+		if !r.AcceptRanges {
+			s.noRange++
+			ranges = "不支持 Range"
+		}
+		console.Printf("  ✓ %d  %s  %s\n", r.StatusCode, size, ranges)
+	case checkNetwork:
+		fields["error"] = r.Err.Error()
+		console.Eprintf("  ✗ %v\n", r.Err)
+	default:
+		console.Eprintf("  ✗ %d %s\n", r.StatusCode, checkStatusText(status, r.StatusCode))
+	}
+	if r.FinalURL != "" {
+		console.Printf("  → 重定向到: %s\n", r.FinalURL)
+	}
+
+	// 与下载记录比较，大小变化通常意味着源文件已更新
+	if status == checkOK && tracked && entry.Size >= 0 && r.Size >= 0 && entry.Size != r.Size {
+		s.sizeChanged++
+		fields["size_changed"] = true
+		fields["recorded_size"] = entry.Size
+		console.Printf("  ! 大小与下载记录不同: 记录 %d 字节，当前 %d 字节\n", entry.Size, r.Size)
+	}
+
+	events.Emit("check", fields)
+}
+
+// finish 输出汇总；有不可用的链接时按失败数返回退出码
+func (s *checkStats) finish() error {
+	ok := s.byStatus[checkOK]
+	failed := s.total - ok - s.notStarted
+
+	console.Printf("\n检查完成: 可用 %d, 不可用 %d", ok, failed)
+	if failed > 0 {
+		console.Printf("（不存在 %d, 需要认证 %d, 其他HTTP错误 %d, 请求失败 %d）",
+			s.byStatus[checkNotFound], s.byStatus[checkAuth], s.byStatus[checkHTTP], s.byStatus[checkNetwork])
+	}
+	console.Println()
+	console.Printf("总大小: %.2f MB", float64(s.bytes)/(1024*1024))
+	if s.unknownSize > 0 {
+		console.Printf("（另有 %d 个大小未知）", s.unknownSize)
+	}
+	console.Println()
+	if s.noRange > 0 {
+		console.Printf("不支持 Range: %d 个\n", s.noRange)
+	}
+	if s.sizeChanged > 0 {
+		console.Printf("大小与下载记录不同: %d 个\n", s.sizeChanged)
+	}
+	if s.notStarted > 0 {
+		console.Printf("已中断: %d 个链接未检查\n", s.notStarted)
+	}
+
+	events.Emit("check_summary", events.Fields{
+		"total":         s.total,
+		"ok":            ok,
+		"not_found":     s.byStatus[checkNotFound],
+		"auth_required": s.byStatus[checkAuth],
+		"http_error":    s.byStatus[checkHTTP],
+		"network_error": s.byStatus[checkNetwork],
+		"size_changed":  s.sizeChanged,
+		"no_range":      s.noRange,
+		"unknown_size":  s.unknownSize,
+		"bytes":         s.bytes,
+		"not_started":   s.notStarted,
+	})
+
+	switch {
+	case s.notStarted > 0:
+		return &codedError{code: exitInterrupted, err: fmt.Errorf("已中断: %d 个链接未检查", s.notStarted)}
+	case failed == 0:
+		return nil
+	case ok == 0:
+		return &codedError{code: exitAllFailed, err: fmt.Errorf("全部链接不可用: %d 个", failed)}
+	default:
+		return &codedError{code: exitPartial, err: fmt.Errorf("部分链接不可用: %d/%d 个", failed, s.total)}
+	}
+}
+
+// classifyCheck 按状态码对检查结果分类
+func classifyCheck(r *download.CheckResult) string {
+	switch {
+	case r.Err != nil:
+		return checkNetwork
+	case r.OK():
+		return checkOK
+	case r.StatusCode == http.StatusNotFound || r.StatusCode == http.StatusGone:
+		return checkNotFound
+	case r.StatusCode == http.StatusUnauthorized || r.StatusCode == http.StatusForbidden:
+		return checkAuth
+	}
+	return checkHTTP
+}
+
+// checkStatusText 不可用链接的说明
+func checkStatusText(status string, code int) string {
+	switch status {
+	case checkNotFound:
+		return "链接不存在"
+	case checkAuth:
+		return "需要认证或无权访问（检查 sources.hosts 的认证设置）"
+	}
+	return http.StatusText(code)
+}
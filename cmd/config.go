@@ -0,0 +1,234 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"text/tabwriter"
+	"time"
+
+	"github.com/difyz9/Link2COS/config"
+	"github.com/difyz9/Link2COS/internal/console"
+	"github.com/difyz9/Link2COS/internal/constants"
+	"github.com/difyz9/Link2COS/internal/cos"
+	"github.com/difyz9/Link2COS/internal/events"
+	"github.com/difyz9/Link2COS/internal/network"
+	"github.com/spf13/cobra"
+)
+
+var configFile string
+
+// configCmd represents the config command
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "查看和检查配置",
+	Long:  `查看配置文件中的 profile，校验配置是否可用。`,
+}
+
+// configListCmd represents the config list command
+var configListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "列出配置文件中的 profile",
+	Long:  `列出配置文件中定义的所有 profile 及其存储桶、地域和URL前缀，* 表示当前选择的 profile。`,
+	Args:  cobra.NoArgs,
+	RunE:  runConfigList,
+}
+
+// configValidateCmd represents the config validate command
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "校验配置并检查代理和存储桶是否可用",
+	Long: `按命令的要求校验配置文件，一次列出所有问题；
+随后检查代理是否可以连接，以及使用配置的密钥能否访问存储桶。`,
+	Args: cobra.NoArgs,
+	RunE: runConfigValidate,
+}
+
+var (
+	validateFor     string
+	validateOffline bool
+)
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.PersistentFlags().StringVarP(&configFile, "config", "c", constants.DefaultConfigFile, "配置文件路径（默认: config.yaml）")
+	configCmd.AddCommand(configListCmd)
+	configCmd.AddCommand(configValidateCmd)
+	configValidateCmd.Flags().StringVar(&validateFor, "for", "sync", "按哪个命令的要求校验: sync、upload 或 download")
+	configValidateCmd.Flags().BoolVar(&validateOffline, "offline", false, "只做静态校验，不检查代理和存储桶")
+}
+
+func runConfigList(cmd *cobra.Command, args []string) error {
+	base, err := config.ReadConfig(configFile, "")
+	if err != nil {
+		return err
+	}
+
+	active := config.ActiveProfile(profileName)
+	if active != "" {
+		if _, ok := base.Profiles[active]; !ok {
+			console.Eprintf("警告: 当前选择的 profile %s 不存在\n", active)
+		}
+	}
+
+	// 表格经过 console 输出，JSON 模式下不混入 stdout 的事件
+	var table bytes.Buffer
+	w := tabwriter.NewWriter(&table, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "\tPROFILE\tBUCKET\tREGION\tURL_PREFIX")
+
+	printRow := func(name, label string, cfg *config.Config) {
+		mark := ""
+		if name == active {
+			mark = "*"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", mark, label, cfg.COS.BucketName, cfg.COS.Region, cfg.COS.URLPrefix)
+		events.Emit("profile", events.Fields{
+			"profile":    name,
+			"active":     name == active,
+			"bucket":     cfg.COS.BucketName,
+			"region":     cfg.COS.Region,
+			"url_prefix": cfg.COS.URLPrefix,
+		})
+	}
+
+	printRow("", "(default)", base)
+	for _, name := range base.ProfileNames() {
+		cfg, err := config.ReadConfig(configFile, name)
+		if err != nil {
+			return err
+		}
+		printRow(name, name, cfg)
+	}
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	console.Printf("%s", table.String())
+	return nil
+}
+
+func runConfigValidate(cmd *cobra.Command, args []string) error {
+	var require config.Requirement
+	switch validateFor {
+	case "sync":
+		require = config.ForSync
+	case "upload":
+		require = config.ForUpload
+	case "download":
+		require = config.ForDownload
+	default:
+		return configError(fmt.Errorf("未知的命令: %s（可选: sync, upload, download）", validateFor))
+	}
+
+	cfg, err := config.ReadConfig(configFile, config.ActiveProfile(profileName))
+	if err != nil {
+		return configError(err)
+	}
+	if err := cfg.Resolve(loadOptions(require)); err != nil {
+		return configError(err)
+	}
+
+	failed := 0
+	report := func(name string, err error) {
+		fields := events.Fields{"check": name, "ok": err == nil}
+		if err != nil {
+			fields["error"] = err.Error()
+			console.Printf("✗ %s: %v\n", name, err)
+			failed++
+		} else {
+			console.Printf("✓ %s\n", name)
+		}
+		events.Emit("validate_result", fields)
+	}
+
+	// 静态校验：一次列出所有问题
+	var verr *config.ValidationError
+	if err := cfg.Validate(require); errors.As(err, &verr) {
+		for _, problem := range verr.Problems {
+			report("配置", errors.New(problem))
+		}
+	} else {
+		report(fmt.Sprintf("配置（%s）", validateFor), err)
+	}
+
+	if !validateOffline {
+		// 代理连通性，输出时隐藏代理凭证
+		for _, proxy := range configuredProxies(cfg) {
+			report(fmt.Sprintf("代理可连接 (%s)", config.RedactURL(proxy.String())), checkProxy(proxy))
+		}
+
+		// 存储桶访问
+		if require&config.RequireCOS != 0 && verr == nil {
+			report(fmt.Sprintf("存储桶可访问 (%s)", cfg.COS.BucketURL), checkBucket(cfg))
+		}
+	}
+
+	if failed > 0 {
+		return configError(fmt.Errorf("发现 %d 个问题", failed))
+	}
+	return nil
+}
+
+// configuredProxies 返回下载源和COS使用的全部代理（去重）
+func configuredProxies(cfg *config.Config) []*url.URL {
+	var list []*url.URL
+	seen := make(map[string]bool)
+	for _, newSelector := range []func(*config.Config) (*network.ProxySelector, error){network.NewSourceProxy, network.NewStorageProxy} {
+		selector, err := newSelector(cfg)
+		if err != nil {
+			// 格式问题已在静态校验中报告
+			continue
+		}
+		for _, u := range selector.Proxies() {
+			if !seen[u.String()] {
+				seen[u.String()] = true
+				list = append(list, u)
+			}
+		}
+	}
+	return list
+}
+
+// checkProxy 检查代理地址能否建立TCP连接
+func checkProxy(u *url.URL) error {
+	host := u.Host
+	if u.Port() == "" {
+		port := map[string]string{"http": "80", "https": "443", "socks5": "1080", "socks5h": "1080"}[u.Scheme]
+		host = net.JoinHostPort(u.Hostname(), port)
+	}
+
+	conn, err := net.DialTimeout("tcp", host, 5*time.Second)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// checkBucket 使用配置的密钥对存储桶发送 HEAD 请求
+func checkBucket(cfg *config.Config) error {
+	client, err := cos.InitClient(cfg, nil)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	resp, err := client.Bucket.Head(ctx)
+	if err == nil {
+		return nil
+	}
+	if resp != nil {
+		switch resp.StatusCode {
+		case http.StatusForbidden:
+			return fmt.Errorf("无访问权限（HTTP 403），请检查密钥权限")
+		case http.StatusNotFound:
+			return fmt.Errorf("存储桶不存在（HTTP 404），请检查 bucket_name 和 region")
+		}
+	}
+	return err
+}
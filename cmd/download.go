@@ -3,10 +3,14 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/difyz9/Link2COS/config"
+	"github.com/difyz9/Link2COS/internal/console"
 	"github.com/difyz9/Link2COS/internal/constants"
 	"github.com/difyz9/Link2COS/internal/download"
+	"github.com/difyz9/Link2COS/internal/progress"
+	"github.com/difyz9/Link2COS/internal/ratelimit"
 	"github.com/difyz9/Link2COS/internal/tracker"
 	"github.com/difyz9/Link2COS/internal/util"
 	"github.com/spf13/cobra"
@@ -16,6 +20,8 @@ var (
 	downloadInputFile  string
 	downloadConfigFile string
 	downloadOutputDir  string
+	downloadLayout     string
+	downloadOnConflict string
 )
 
 // downloadCmd represents the download command
@@ -30,15 +36,21 @@ func init() {
 	rootCmd.AddCommand(downloadCmd)
 	downloadCmd.Flags().StringVarP(&downloadInputFile, "input", "i", "", "输入文件路径（必填）")
 	downloadCmd.Flags().StringVarP(&downloadOutputDir, "output", "o", constants.DefaultOutputDir, "下载文件保存目录（默认: downloads）")
+	downloadCmd.Flags().StringVar(&downloadOutputDir, "output-dir", constants.DefaultOutputDir, "同 -o/--output")
+	downloadCmd.Flags().MarkHidden("output-dir")
+	downloadCmd.Flags().StringVar(&downloadLayout, "layout", "", "本地目录布局: flat 或 mirror（默认读取配置 download.layout，否则 flat）")
+	downloadCmd.Flags().StringVar(&downloadOnConflict, "on-conflict", "", "本地路径冲突策略: error、suffix 或 overwrite（默认读取配置 download.on_conflict，否则 error）")
 	downloadCmd.Flags().StringVarP(&downloadConfigFile, "config", "c", constants.DefaultConfigFile, "配置文件路径（默认: config.yaml）")
+	downloadCmd.Flags().BoolVar(&dryRun, "dry-run", false, "只探测链接并输出计划（本地路径、大小），不下载，也不写入下载记录")
+	addReportFlag(downloadCmd)
 	downloadCmd.MarkFlagRequired("input")
 }
 
 func runDownload(cmd *cobra.Command, args []string) error {
 	// 加载配置
-	cfg, err := config.LoadConfig(downloadConfigFile)
+	cfg, err := config.LoadConfig(downloadConfigFile, loadOptions(config.ForDownload))
 	if err != nil {
-		return fmt.Errorf("加载配置失败: %w", err)
+		return configError(fmt.Errorf("加载配置失败: %w", err))
 	}
 
 	// 初始化链接跟踪器
@@ -46,58 +58,142 @@ func runDownload(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("初始化链接跟踪器失败: %w", err)
 	}
-	fmt.Printf("已下载链接数: %d\n", linkTracker.GetDownloadedCount())
+	console.Printf("已下载链接数: %d\n", linkTracker.GetDownloadedCount())
+
+	// 解析目录布局和冲突策略（命令行参数优先于配置文件）
+	layoutName := cfg.Download.Layout
+	if downloadLayout != "" {
+		layoutName = downloadLayout
+	}
+	layout, err := download.ParseLayout(layoutName)
+	if err != nil {
+		return configError(err)
+	}
+	policyName := cfg.Download.OnConflict
+	if downloadOnConflict != "" {
+		policyName = downloadOnConflict
+	}
+	policy, err := download.ParseConflictPolicy(policyName)
+	if err != nil {
+		return configError(err)
+	}
 
 	// 创建HTTP客户端和下载器
-	httpClient := download.CreateHTTPClient(cfg)
+	limits, err := ratelimit.NewLimits(cfg.Bandwidth)
+	if err != nil {
+		return configError(err)
+	}
+	defer limits.Stop()
+	httpClient, err := download.CreateHTTPClient(cfg, limits)
+	if err != nil {
+		return configError(err)
+	}
 	downloader := download.NewDownloader(httpClient, downloadOutputDir)
+	downloader.SetLayout(layout, cfg.COS.URLPrefix)
+	downloader.SetConflictPolicy(policy)
 
 	// 读取输入文件中的链接
 	links, err := util.ReadLinksFromFile(downloadInputFile)
 	if err != nil {
-		return fmt.Errorf("读取输入文件失败: %w", err)
+		return configError(fmt.Errorf("读取输入文件失败: %w", err))
 	}
 
-	fmt.Printf("共找到 %d 个链接\n", len(links))
-	fmt.Printf("下载目录: %s\n", downloadOutputDir)
+	console.Printf("共找到 %d 个链接\n", len(links))
+	console.Printf("下载目录: %s（布局: %s，冲突策略: %s）\n", downloadOutputDir, layout, policy)
+
+	rc := newRunContext()
+	defer rc.release()
+
+	if dryRun {
+		return planDownload(rc, downloader, links, linkTracker)
+	}
 
 	// 确保输出目录存在
 	if err := os.MkdirAll(downloadOutputDir, 0755); err != nil {
 		return fmt.Errorf("创建输出目录失败: %w", err)
 	}
 
-	// 处理每个链接
-	successCount := 0
-	failCount := 0
-	skipCount := 0
+	// 进度显示：终端上为进度条，否则定期输出
+	prog := progress.New(len(links))
+	defer prog.Stop()
+	downloader.SetProgress(prog)
 
+	// 处理每个链接，收到中断信号后不再开始新的链接
+	stats := newRunStats("download", len(links))
 	for i, link := range links {
-		fmt.Printf("[%d/%d] 下载: %s\n", i+1, len(links), link)
+		if rc.stopped() {
+			stats.interrupt(links[i:])
+			break
+		}
+		console.Printf("[%d/%d] 下载: %s\n", i+1, len(links), link)
+		stats.linkStarted(i+1, link)
 
 		// 检查链接是否已下载
 		if linkTracker.IsDownloaded(link) {
-			fmt.Println("  ⊘ 跳过（已下载）")
-			skipCount++
+			console.Println("  ⊘ 跳过（已下载）")
+			stats.linkSkipped(link, skipAlreadyDownloaded)
+			prog.LinkDone()
 			continue
 		}
 
 		// 下载文件
-		_, err := downloader.DownloadFile(link)
+		start := time.Now()
+		result, err := downloader.DownloadFile(rc.work, link)
+		prog.LinkDone()
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "  ✗ 失败: %v\n", err)
-			failCount++
+			console.Eprintf("  ✗ 失败: %v\n", err)
+			stats.linkFailed(link, err, time.Since(start))
 			continue
 		}
 
 		// 标记为已下载
-		if err := linkTracker.MarkDownloaded(link); err != nil {
-			fmt.Fprintf(os.Stderr, "  警告: 记录链接失败: %v\n", err)
+		if err := linkTracker.MarkDownloaded(tracker.Entry{Link: link, Destination: result.LocalPath, Size: result.Size}); err != nil {
+			console.Eprintf("  警告: 记录链接失败: %v\n", err)
 		}
 
-		fmt.Println("  ✓ 成功")
-		successCount++
+		console.Println("  ✓ 成功")
+		stats.linkDone(&linkResult{
+			Link:        link,
+			Destination: result.LocalPath,
+			Size:        result.Size,
+			Checksums:   result.Checksums,
+			Duration:    time.Since(start),
+		})
 	}
 
-	fmt.Printf("\n完成: 成功 %d, 失败 %d, 跳过 %d\n", successCount, failCount, skipCount)
-	return nil
+	prog.Stop()
+	console.Printf("\n完成: 成功 %d, 失败 %d, 跳过 %d\n", stats.success, stats.failed, stats.skipped)
+	if stats.notStarted > 0 {
+		console.Printf("已中断: %d 个链接未开始\n", stats.notStarted)
+	}
+	return stats.finish()
+}
+
+// planDownload dry-run：探测每个链接的大小和文件名，按布局和冲突策略计算本地路径
+func planDownload(rc *runContext, downloader *download.Downloader, links []string, linkTracker *tracker.LinkTracker) error {
+	plan := newPlanStats("download", len(links))
+	for i, link := range links {
+		if rc.stopped() {
+			break
+		}
+		console.Printf("[%d/%d] %s\n", i+1, len(links), link)
+
+		entry := planEntry{Link: link, Action: actionTransfer}
+		if linkTracker.IsDownloaded(link) {
+			entry.Action, entry.Reason = actionSkip, skipAlreadyDownloaded
+			plan.add(entry)
+			continue
+		}
+
+		probe, err := downloader.Probe(rc.work, link)
+		if err == nil {
+			entry.Size = probe.Size
+			entry.Destination, err = downloader.PlanLocalPath(link, probe.Filename)
+		}
+		if err != nil {
+			entry.Action, entry.Err = actionError, err
+		}
+		plan.add(entry)
+	}
+	return plan.finish()
 }
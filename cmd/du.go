@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/difyz9/Link2COS/internal/console"
+	"github.com/difyz9/Link2COS/internal/constants"
+	"github.com/difyz9/Link2COS/internal/cos"
+	"github.com/difyz9/Link2COS/internal/events"
+	"github.com/difyz9/Link2COS/internal/util"
+	"github.com/spf13/cobra"
+)
+
+var (
+	duConfigFile string
+	duDepth      int
+)
+
+// duCmd represents the du command
+var duCmd = &cobra.Command{
+	Use:   "du [cos://<前缀>]",
+	Short: "统计存储桶中对象的数量和大小",
+	Long: `统计前缀下的对象数量和总大小，按目录层级和存储类型分别汇总。
+每个目录的统计包含其下所有层级的对象，--depth 控制列出的目录层级。
+省略前缀时统计整个存储桶。`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runDu,
+}
+
+func init() {
+	rootCmd.AddCommand(duCmd)
+	duCmd.Flags().StringVarP(&duConfigFile, "config", "c", constants.DefaultConfigFile, "配置文件路径（默认: config.yaml）")
+	duCmd.Flags().IntVarP(&duDepth, "depth", "d", 1, "列出的目录层级，0 表示只输出合计")
+}
+
+// usage 一组对象的数量和大小
+type usage struct {
+	objects int
+	bytes   int64
+}
+
+func (u *usage) add(size int64) {
+	u.objects++
+	u.bytes += size
+}
+
+func runDu(cmd *cobra.Command, args []string) error {
+	prefix, err := listPrefixArg(args)
+	if err != nil {
+		return configError(err)
+	}
+	if duDepth < 0 {
+		return configError(fmt.Errorf("--depth 不能小于 0"))
+	}
+
+	client, err := initListClient(duConfigFile)
+	if err != nil {
+		return err
+	}
+
+	rc := newRunContext()
+	defer rc.release()
+
+	// 目录按前缀统计，只用于列出的层级；对象直接位于这些层级时只计入上级和合计
+	var total usage
+	dirs := make(map[string]*usage)
+	classes := make(map[string]*usage)
+	opts := cos.ListOptions{Prefix: prefix}
+	for {
+		if rc.stopped() {
+			return interruptedError()
+		}
+		page, err := cos.ListObjectsPage(rc.work, client, opts)
+		if err != nil {
+			if rc.stopped() {
+				return interruptedError()
+			}
+			return err
+		}
+		for _, obj := range page.Objects {
+			if strings.HasSuffix(obj.Key, "/") && obj.Size == 0 {
+				continue
+			}
+			total.add(obj.Size)
+
+			class := storageClass(obj.StorageClass)
+			if classes[class] == nil {
+				classes[class] = &usage{}
+			}
+			classes[class].add(obj.Size)
+
+			segments := strings.Split(strings.TrimPrefix(obj.Key, prefix), "/")
+			for level := 1; level <= duDepth && level < len(segments); level++ {
+				dir := prefix + strings.Join(segments[:level], "/") + "/"
+				if dirs[dir] == nil {
+					dirs[dir] = &usage{}
+				}
+				dirs[dir].add(obj.Size)
+			}
+		}
+		if page.NextMarker == "" {
+			break
+		}
+		opts.Marker = page.NextMarker
+	}
+
+	if len(dirs) > 0 {
+		console.Println("按目录:")
+		for _, dir := range sortedKeys(dirs) {
+			u := dirs[dir]
+			console.Printf("  %10s  %8d 个对象  %s\n", util.FormatBytes(u.bytes), u.objects, dir)
+			events.Emit("du", events.Fields{"prefix": dir, "objects": u.objects, "bytes": u.bytes})
+		}
+	}
+	if len(classes) > 0 {
+		console.Println("按存储类型:")
+		for _, class := range sortedKeys(classes) {
+			u := classes[class]
+			console.Printf("  %10s  %8d 个对象  %s\n", util.FormatBytes(u.bytes), u.objects, class)
+			events.Emit("du_storage_class", events.Fields{"storage_class": class, "objects": u.objects, "bytes": u.bytes})
+		}
+	}
+
+	name := prefix
+	if name == "" {
+		name = "（整个存储桶）"
+	}
+	console.Printf("合计: %s  %d 个对象，%s（%d 字节）\n", name, total.objects, util.FormatBytes(total.bytes), total.bytes)
+	events.Emit("du_summary", events.Fields{"prefix": prefix, "objects": total.objects, "bytes": total.bytes})
+	return nil
+}
+
+// sortedKeys 按名称排序的键，目录按此顺序输出时子目录紧跟在上级之后
+func sortedKeys(m map[string]*usage) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
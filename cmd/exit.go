@@ -0,0 +1,48 @@
+package cmd
+
+import "errors"
+
+// 退出码，便于 cron 和 CI 判断运行结果
+const (
+	exitOK        = 0 // 全部成功（包括全部跳过）
+	exitFailure   = 1 // 其他错误
+	exitConfig    = 2 // 配置或参数错误
+	exitPartial   = 3 // 部分链接失败
+	exitAllFailed = 4 // 本次尝试的链接全部失败
+
+	exitInterrupted = 130 // 被 SIGINT/SIGTERM 中断（128 + SIGINT）
+)
+
+// codedError 带退出码的错误
+type codedError struct {
+	code int
+	err  error
+}
+
+func (e *codedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *codedError) Unwrap() error {
+	return e.err
+}
+
+// configError 标记为配置或参数错误
+func configError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &codedError{code: exitConfig, err: err}
+}
+
+// exitCode 返回错误对应的退出码
+func exitCode(err error) int {
+	if err == nil {
+		return exitOK
+	}
+	var coded *codedError
+	if errors.As(err, &coded) {
+		return coded.code
+	}
+	return exitFailure
+}
@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/difyz9/Link2COS/config"
+	"github.com/difyz9/Link2COS/internal/console"
+	"github.com/difyz9/Link2COS/internal/constants"
+	"github.com/difyz9/Link2COS/internal/cos"
+	"github.com/difyz9/Link2COS/internal/events"
+	"github.com/difyz9/Link2COS/internal/util"
+	"github.com/spf13/cobra"
+	cosSDK "github.com/tencentyun/cos-go-sdk-v5"
+)
+
+var (
+	lsConfigFile string
+	lsRecursive  bool
+	lsLong       bool
+	lsLimit      int
+	lsMarker     string
+)
+
+// lsCmd represents the ls command
+var lsCmd = &cobra.Command{
+	Use:   "ls [cos://<前缀>]",
+	Short: "列出存储桶中的对象",
+	Long: `列出存储桶中前缀下的对象，默认只列出下一级，更深的对象合并为目录（以 / 结尾）。
+省略前缀时列出存储桶的根目录。
+
+结果较多时自动翻页；使用 --limit 只列出一部分，结尾会给出继续列出所需的 --marker。`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runLs,
+}
+
+func init() {
+	rootCmd.AddCommand(lsCmd)
+	lsCmd.Flags().StringVarP(&lsConfigFile, "config", "c", constants.DefaultConfigFile, "配置文件路径（默认: config.yaml）")
+	lsCmd.Flags().BoolVarP(&lsRecursive, "recursive", "r", false, "递归列出前缀下的所有对象")
+	lsCmd.Flags().BoolVarP(&lsLong, "long", "l", false, "显示修改时间、大小、存储类型和 ETag")
+	lsCmd.Flags().IntVar(&lsLimit, "limit", 0, "最多列出的条目数，0 表示全部")
+	lsCmd.Flags().StringVar(&lsMarker, "marker", "", "从该对象键之后开始列出（上次 --limit 结尾给出的位置）")
+}
+
+func runLs(cmd *cobra.Command, args []string) error {
+	prefix, err := listPrefixArg(args)
+	if err != nil {
+		return configError(err)
+	}
+	if lsLimit < 0 {
+		return configError(fmt.Errorf("--limit 不能小于 0"))
+	}
+
+	client, err := initListClient(lsConfigFile)
+	if err != nil {
+		return err
+	}
+
+	rc := newRunContext()
+	defer rc.release()
+
+	opts := cos.ListOptions{Prefix: prefix, Marker: lsMarker}
+	if !lsRecursive {
+		opts.Delimiter = "/"
+	}
+
+	var objects, dirs int
+	var bytes int64
+	for {
+		if lsLimit > 0 {
+			opts.MaxKeys = lsLimit - objects - dirs
+		}
+		page, err := cos.ListObjectsPage(rc.work, client, opts)
+		if err != nil {
+			if rc.stopped() {
+				return interruptedError()
+			}
+			return err
+		}
+
+		// 目录和对象按键的顺序合并输出
+		i, j := 0, 0
+		for i < len(page.Prefixes) || j < len(page.Objects) {
+			if j == len(page.Objects) || (i < len(page.Prefixes) && page.Prefixes[i] < page.Objects[j].Key) {
+				dirs++
+				printListedDir(page.Prefixes[i])
+				i++
+				continue
+			}
+			objects++
+			bytes += page.Objects[j].Size
+			printListedObject(page.Objects[j])
+			j++
+		}
+
+		opts.Marker = page.NextMarker
+		// 中断时在当前页之后停止，结尾给出继续列出的位置
+		if opts.Marker == "" || (lsLimit > 0 && objects+dirs >= lsLimit) || rc.stopped() {
+			break
+		}
+	}
+
+	console.Printf("\n共 %d 个对象（%s）", objects, util.FormatBytes(bytes))
+	if dirs > 0 {
+		console.Printf("，%d 个目录", dirs)
+	}
+	console.Println()
+	if opts.Marker != "" {
+		console.Printf("还有更多结果，继续列出: --marker %q\n", opts.Marker)
+	}
+
+	events.Emit("list_summary", events.Fields{
+		"prefix":      prefix,
+		"objects":     objects,
+		"dirs":        dirs,
+		"bytes":       bytes,
+		"next_marker": opts.Marker,
+	})
+	if rc.stopped() && opts.Marker != "" {
+		return interruptedError()
+	}
+	return nil
+}
+
+// printListedDir 输出一个合并的目录
+func printListedDir(prefix string) {
+	if lsLong {
+		console.Printf("%19s  %10s  %s\n", "", "DIR", prefix)
+	} else {
+		console.Println(prefix)
+	}
+	events.Emit("dir", events.Fields{"prefix": prefix})
+}
+
+// printListedObject 输出一个对象
+func printListedObject(obj cos.ListedObject) {
+	if lsLong {
+		console.Printf("%s  %10s  %-12s  %-36s  %s\n",
+			obj.LastModified.Local().Format("2006-01-02 15:04:05"),
+			util.FormatBytes(obj.Size),
+			storageClass(obj.StorageClass),
+			obj.ETag,
+			obj.Key)
+	} else {
+		console.Println(obj.Key)
+	}
+	events.Emit("object", events.Fields{
+		"key":           obj.Key,
+		"size":          obj.Size,
+		"last_modified": obj.LastModified,
+		"etag":          obj.ETag,
+		"storage_class": obj.StorageClass,
+	})
+}
+
+// listPrefixArg 解析 ls、du 的可选参数 cos://前缀，省略时为存储桶根目录
+func listPrefixArg(args []string) (string, error) {
+	if len(args) == 0 {
+		return "", nil
+	}
+	return cos.ParseURL(args[0])
+}
+
+// initListClient 加载配置并创建只用于查询的COS客户端
+func initListClient(configPath string) (*cosSDK.Client, error) {
+	cfg, err := config.LoadConfig(configPath, loadOptions(config.ForList))
+	if err != nil {
+		return nil, configError(fmt.Errorf("加载配置失败: %w", err))
+	}
+	client, err := cos.InitClient(cfg, nil)
+	if err != nil {
+		return nil, fmt.Errorf("初始化COS客户端失败: %w", err)
+	}
+	return client, nil
+}
+
+// storageClass 存储类型，未返回时显示为 -
+func storageClass(class string) string {
+	if class == "" {
+		return "-"
+	}
+	return class
+}
@@ -0,0 +1,322 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/difyz9/Link2COS/config"
+	"github.com/difyz9/Link2COS/internal/checksum"
+	"github.com/difyz9/Link2COS/internal/console"
+	"github.com/difyz9/Link2COS/internal/constants"
+	"github.com/difyz9/Link2COS/internal/cos"
+	"github.com/difyz9/Link2COS/internal/events"
+	"github.com/difyz9/Link2COS/internal/ratelimit"
+	"github.com/difyz9/Link2COS/internal/util"
+	"github.com/spf13/cobra"
+	cosSDK "github.com/tencentyun/cos-go-sdk-v5"
+)
+
+var (
+	mirrorConfigFile string
+	mirrorDelete     bool
+)
+
+// mirrorCmd represents the mirror command
+var mirrorCmd = &cobra.Command{
+	Use:   "mirror <本地目录> cos://<前缀>/",
+	Short: "增量同步本地目录到COS前缀",
+	Long: `比较本地目录和COS前缀下的对象，只上传新增或变化的文件；
+使用 --delete 时删除本地已不存在的文件对应的对象。
+
+判断文件是否变化：大小不同即为变化；大小相同时依次比较上传时记录的修改时间（x-cos-meta-mtime）、
+对象的 CRC64 校验值，都没有时比较本地修改时间和对象的最后修改时间。`,
+	Args: cobra.ExactArgs(2),
+	RunE: runMirror,
+}
+
+func init() {
+	rootCmd.AddCommand(mirrorCmd)
+	mirrorCmd.Flags().StringVarP(&mirrorConfigFile, "config", "c", constants.DefaultConfigFile, "配置文件路径（默认: config.yaml）")
+	mirrorCmd.Flags().BoolVar(&mirrorDelete, "delete", false, "删除本地已不存在的文件对应的对象（被 --include/--exclude 过滤掉的路径不会删除）")
+	mirrorCmd.Flags().BoolVar(&dryRun, "dry-run", false, "只输出要上传和删除的文件，不上传、不删除")
+	mirrorCmd.Flags().StringArrayVar(&uploadInclude, "include", nil, "只同步匹配的文件，例如 *.safetensors，可重复指定")
+	mirrorCmd.Flags().StringArrayVar(&uploadExclude, "exclude", nil, "排除匹配的文件和目录，例如 .git 或 **/*.tmp，可重复指定")
+	mirrorCmd.Flags().StringVar(&uploadSymlinks, "symlinks", "skip", "符号链接的处理方式: skip、follow 或 error")
+	mirrorCmd.Flags().IntVarP(&uploadConcurrency, "concurrency", "j", 4, "同时比较和上传的文件数")
+	addReportFlag(mirrorCmd)
+}
+
+// mirror 中文件的变化
+const (
+	changeNew       = "new"       // COS 中没有对应的对象
+	changeSize      = "size"      // 大小不同
+	changeContent   = "content"   // 大小相同，CRC64 不同
+	changeNewer     = "newer"     // 无法比较校验值，本地文件比对象新
+	changeUnchanged = "unchanged" // 与对象相同
+)
+
+// mirrorChange 本地文件与对象的比较结果
+type mirrorChange struct {
+	job    dirUploadJob
+	change string
+	err    error // 查询对象失败，按变化处理
+}
+
+func runMirror(cmd *cobra.Command, args []string) error {
+	localDir := args[0]
+	info, err := os.Stat(localDir)
+	if err != nil {
+		return configError(fmt.Errorf("本地目录不存在: %s", localDir))
+	}
+	if !info.IsDir() {
+		return configError(fmt.Errorf("不是目录: %s", localDir))
+	}
+	prefix, err := cos.ParseURL(args[1])
+	if err != nil {
+		return configError(err)
+	}
+	prefix = dirKeyPrefix(prefix)
+
+	policy, err := util.ParseSymlinkPolicy(uploadSymlinks)
+	if err != nil {
+		return configError(err)
+	}
+	for _, patterns := range [][]string{uploadInclude, uploadExclude} {
+		if err := util.CheckGlobs(patterns); err != nil {
+			return configError(err)
+		}
+	}
+	if uploadConcurrency < 1 {
+		return configError(fmt.Errorf("--concurrency 必须大于 0"))
+	}
+
+	cfg, err := config.LoadConfig(mirrorConfigFile, loadOptions(config.ForUpload))
+	if err != nil {
+		return configError(fmt.Errorf("加载配置失败: %w", err))
+	}
+	limits, err := ratelimit.NewLimits(cfg.Bandwidth)
+	if err != nil {
+		return configError(err)
+	}
+	defer limits.Stop()
+	client, err := cos.InitClient(cfg, limits)
+	if err != nil {
+		return fmt.Errorf("初始化COS客户端失败: %w", err)
+	}
+
+	opts := util.WalkOptions{Include: uploadInclude, Exclude: uploadExclude, Symlinks: policy}
+	walked, err := util.WalkFiles(localDir, opts)
+	if err != nil {
+		return fmt.Errorf("遍历目录失败: %w", err)
+	}
+	for _, link := range walked.Symlinks {
+		console.Printf("  ⊘ 跳过符号链接: %s\n", link)
+	}
+
+	rc := newRunContext()
+	defer rc.release()
+
+	objects, err := cos.ListObjects(rc.work, client, prefix)
+	if err != nil {
+		return err
+	}
+	console.Printf("本地 %d 个文件，COS 前缀 %q 下 %d 个对象\n", len(walked.Files), prefix, len(objects))
+
+	// 比较本地文件和对象
+	remote := make(map[string]cos.ListedObject, len(objects))
+	for _, obj := range objects {
+		remote[strings.TrimPrefix(obj.Key, prefix)] = obj
+	}
+	changes := compareMirror(rc.work, client, walked.Files, prefix, remote)
+
+	// 本地已不存在的文件；被过滤或跳过的路径不删除
+	local := make(map[string]bool, len(walked.Files))
+	for _, f := range walked.Files {
+		local[f.Rel] = true
+	}
+	var deletes []string
+	for _, obj := range objects {
+		rel := strings.TrimPrefix(obj.Key, prefix)
+		if !local[rel] && opts.Selected(rel) && !underSymlink(rel, walked.Symlinks) {
+			deletes = append(deletes, obj.Key)
+		}
+	}
+	if !mirrorDelete && len(deletes) > 0 {
+		console.Printf("COS 中有 %d 个对象在本地已不存在，未指定 --delete，予以保留\n", len(deletes))
+		deletes = nil
+	}
+
+	var uploads []dirUploadJob
+	var unchanged []mirrorChange
+	for _, c := range changes {
+		if c.change == changeUnchanged {
+			unchanged = append(unchanged, c)
+			continue
+		}
+		c.job.index = len(uploads) + 1
+		uploads = append(uploads, c.job)
+		printMirrorChange(c)
+	}
+	for _, key := range deletes {
+		console.Printf("  - %s（本地已删除）\n", strings.TrimPrefix(key, prefix))
+		events.Emit("plan", events.Fields{"link": key, "action": "delete", "destination": key})
+	}
+
+	var bytes int64
+	for _, job := range uploads {
+		bytes += job.file.Size
+	}
+	console.Printf("\n计划: 上传 %d 个（%.2f MB），删除 %d 个，未变化 %d 个\n",
+		len(uploads), float64(bytes)/(1024*1024), len(deletes), len(unchanged))
+
+	if dryRun {
+		events.Emit("plan_summary", events.Fields{
+			"command":  "mirror",
+			"total":    len(changes) + len(deletes),
+			"transfer": len(uploads),
+			"delete":   len(deletes),
+			"skipped":  len(unchanged),
+			"bytes":    bytes,
+		})
+		console.Println("（dry-run：未上传，也未删除）")
+		return nil
+	}
+
+	stats := newRunStats("mirror", len(changes)+len(deletes))
+	for _, c := range unchanged {
+		stats.linkSkipped(c.job.file.Path, skipUnchanged)
+	}
+	if len(uploads) > 0 {
+		transferDirJobs(rc, client, uploads, false, stats)
+	}
+
+	// 上传全部成功后才删除，避免上传失败时同时失去旧对象
+	switch {
+	case len(deletes) == 0:
+	case stats.failed > 0 || stats.interrupted:
+		console.Eprintf("上传未全部完成，不删除对象（%d 个）\n", len(deletes))
+		stats.notRun(deletes)
+	default:
+		deleteMirrorObjects(rc.work, client, deletes, prefix, stats)
+	}
+
+	console.Printf("\n完成: 上传 %d, 删除 %d, 未变化 %d, 失败 %d\n", stats.success, stats.deleted, stats.skipped, stats.failed)
+	if stats.notStarted > 0 {
+		console.Printf("未执行: %d 个\n", stats.notStarted)
+	}
+	return stats.finish()
+}
+
+// compareMirror 并发比较本地文件和对象，结果按本地文件的顺序返回
+func compareMirror(ctx context.Context, client *cosSDK.Client, files []util.LocalFile, prefix string, remote map[string]cos.ListedObject) []mirrorChange {
+	changes := make([]mirrorChange, len(files))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < uploadConcurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				f := files[i]
+				c := mirrorChange{job: dirUploadJob{file: f, key: prefix + f.Rel}}
+				obj, ok := remote[f.Rel]
+				if ok {
+					c.change, c.err = compareObject(ctx, client, f, obj)
+				} else {
+					c.change = changeNew
+				}
+				changes[i] = c
+			}
+		}()
+	}
+	for i := range files {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	return changes
+}
+
+// compareObject 比较本地文件和同名对象
+func compareObject(ctx context.Context, client *cosSDK.Client, f util.LocalFile, obj cos.ListedObject) (string, error) {
+	if obj.Size != f.Size {
+		return changeSize, nil
+	}
+
+	info, err := cos.StatObject(ctx, client, obj.Key)
+	if err != nil || info == nil {
+		return changeNew, err
+	}
+	mtime := f.Info.ModTime().Unix()
+	switch {
+	case !info.Mtime.IsZero() && info.Mtime.Unix() == mtime:
+		return changeUnchanged, nil
+	case info.CRC64 != "":
+		sums, err := checksum.File(f.Path)
+		if err != nil {
+			return changeContent, err
+		}
+		if sums.CRC64 == info.CRC64 {
+			return changeUnchanged, nil
+		}
+		return changeContent, nil
+	case mtime > obj.LastModified.Unix():
+		return changeNewer, nil
+	}
+	return changeUnchanged, nil
+}
+
+// printMirrorChange 输出一个要上传的文件
+func printMirrorChange(c mirrorChange) {
+	mark, reason := "~", map[string]string{
+		changeSize:    "大小不同",
+		changeContent: "内容不同",
+		changeNewer:   "本地较新",
+	}[c.change]
+	if c.change == changeNew {
+		mark, reason = "+", "新文件"
+	}
+	if c.err != nil {
+		reason = fmt.Sprintf("查询对象失败，重新上传: %v", c.err)
+	}
+	console.Printf("  %s %s（%s，%.2f MB）\n", mark, c.job.file.Rel, reason, float64(c.job.file.Size)/(1024*1024))
+	events.Emit("plan", events.Fields{
+		"link":        c.job.file.Path,
+		"action":      actionTransfer,
+		"reason":      c.change,
+		"destination": c.job.key,
+		"size":        c.job.file.Size,
+	})
+}
+
+// deleteMirrorObjects 批量删除对象并计入统计
+func deleteMirrorObjects(ctx context.Context, client *cosSDK.Client, keys []string, prefix string, stats *runStats) {
+	start := time.Now()
+	failed, err := cos.DeleteObjects(ctx, client, keys)
+	if err != nil {
+		console.Eprintf("  ✗ %v\n", err)
+	}
+	for _, key := range keys {
+		if reason, ok := failed[key]; ok {
+			console.Eprintf("  ✗ 删除失败: %s: %s\n", strings.TrimPrefix(key, prefix), reason)
+			stats.linkFailed(key, fmt.Errorf("删除失败: %s", reason), time.Since(start))
+			continue
+		}
+		console.Printf("  ✓ 已删除: %s\n", strings.TrimPrefix(key, prefix))
+		stats.objectDeleted(key)
+	}
+}
+
+// underSymlink 相对路径是否位于被跳过的符号链接下
+func underSymlink(rel string, links []string) bool {
+	for _, link := range links {
+		if rel == link || strings.HasPrefix(rel, link+"/") {
+			return true
+		}
+	}
+	return false
+}
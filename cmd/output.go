@@ -0,0 +1,193 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/difyz9/Link2COS/internal/checksum"
+	"github.com/difyz9/Link2COS/internal/console"
+	"github.com/difyz9/Link2COS/internal/events"
+	"github.com/spf13/cobra"
+)
+
+// 输出格式：text 为面向人的文字输出，json 为 stdout 上逐行输出的事件
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+// setupOutput 根据 --format 切换输出模式，并检查 --report 的文件类型
+// JSON 模式下 stdout 只输出事件，面向人的信息改为输出到 stderr
+func setupOutput(cmd *cobra.Command, args []string) error {
+	// 参数已解析成功，之后的运行错误不再打印用法，错误信息由 Execute 统一输出
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+
+	switch outputFormat {
+	case outputText:
+	case outputJSON:
+		console.SetOutput(os.Stderr)
+		events.Enable(os.Stdout)
+	default:
+		return configError(fmt.Errorf("不支持的输出格式: %s（可选: text、json）", outputFormat))
+	}
+	return configError(checkReportPath(reportFile))
+}
+
+// linkResult 单个链接（或本地文件）的处理结果
+type linkResult struct {
+	Link        string
+	Destination string // COS 路径或本地保存路径
+	Size        int64
+	Checksums   checksum.Sums
+	Duration    time.Duration
+	Skipped     bool // 目标已存在，未传输（--skip-existing）
+}
+
+// runStats 一次运行的统计和每个链接的结果
+type runStats struct {
+	command string
+	start   time.Time
+	total   int
+	success int
+	failed  int
+	skipped int
+	deleted int // mirror --delete 删除的对象数
+	bytes   int64
+	results []linkReport
+
+	interrupted bool // 收到中断信号，有链接未开始或被中止
+	notStarted  int  // 收到中断信号后未开始的链接数
+}
+
+func newRunStats(command string, total int) *runStats {
+	return &runStats{command: command, start: time.Now(), total: total}
+}
+
+// linkStarted 开始处理第 index 个链接（从 1 开始）
+func (s *runStats) linkStarted(index int, link string) {
+	events.Emit("link_started", events.Fields{"link": link, "index": index, "total": s.total})
+}
+
+// linkSkipped 链接已处理过，跳过
+func (s *runStats) linkSkipped(link, reason string) {
+	s.skipped++
+	s.results = append(s.results, linkReport{Link: link, Status: statusSkipped, Error: reason})
+	events.Emit("link_skipped", events.Fields{"link": link, "reason": reason})
+}
+
+// linkDone 链接处理成功
+func (s *runStats) linkDone(r *linkResult) {
+	s.success++
+	s.bytes += r.Size
+	s.results = append(s.results, linkReport{
+		Link:        r.Link,
+		Status:      statusSuccess,
+		Size:        r.Size,
+		DurationMs:  r.Duration.Milliseconds(),
+		Throughput:  throughput(r.Size, r.Duration),
+		Destination: r.Destination,
+	})
+	events.Emit("link_done", events.Fields{
+		"link":        r.Link,
+		"destination": r.Destination,
+		"size":        r.Size,
+		"sha256":      r.Checksums.SHA256,
+		"crc64":       r.Checksums.CRC64,
+		"duration_ms": r.Duration.Milliseconds(),
+	})
+}
+
+// objectDeleted mirror --delete 删除了本地已不存在的文件对应的对象
+func (s *runStats) objectDeleted(key string) {
+	s.deleted++
+	s.results = append(s.results, linkReport{Link: key, Status: statusDeleted, Destination: key})
+	events.Emit("object_deleted", events.Fields{"key": key})
+}
+
+// linkFailed 链接处理失败
+func (s *runStats) linkFailed(link string, err error, duration time.Duration) {
+	status := statusFailed
+	if errors.Is(err, context.Canceled) {
+		// 第二次中断信号中止的传输
+		status = statusInterrupted
+		s.interrupted = true
+	}
+	s.failed++
+	s.results = append(s.results, linkReport{
+		Link:       link,
+		Status:     status,
+		DurationMs: duration.Milliseconds(),
+		Error:      err.Error(),
+	})
+	events.Emit("link_failed", events.Fields{
+		"link":        link,
+		"error":       err.Error(),
+		"duration_ms": duration.Milliseconds(),
+	})
+}
+
+// interrupt 收到中断信号，剩余的链接不再开始
+func (s *runStats) interrupt(remaining []string) {
+	s.interrupted = true
+	s.notStarted += len(remaining)
+	for _, link := range remaining {
+		s.results = append(s.results, linkReport{Link: link, Status: statusNotStarted})
+	}
+	events.Emit("interrupted", events.Fields{"not_started": len(remaining)})
+}
+
+// notRun 因前面的步骤失败而没有执行的项目，例如 mirror 上传失败后不再删除对象
+func (s *runStats) notRun(links []string) {
+	s.notStarted += len(links)
+	for _, link := range links {
+		s.results = append(s.results, linkReport{Link: link, Status: statusNotStarted})
+	}
+}
+
+// finish 输出运行汇总事件、写入报告文件，并根据结果返回带退出码的错误
+func (s *runStats) finish() error {
+	if reportFile != "" {
+		if err := writeReport(reportFile, s); err != nil {
+			console.Eprintf("警告: 写入报告失败: %v\n", err)
+		} else {
+			console.Printf("报告已保存: %s\n", reportFile)
+		}
+	}
+
+	s.summary()
+
+	switch {
+	case s.interrupted && s.notStarted > 0:
+		return &codedError{code: exitInterrupted, err: fmt.Errorf("已中断: %d 个链接未开始", s.notStarted)}
+	case s.interrupted:
+		return interruptedError()
+	case s.failed == 0:
+		return nil
+	case s.success == 0 && s.deleted == 0:
+		return &codedError{code: exitAllFailed, err: fmt.Errorf("全部失败: %d 个链接", s.failed)}
+	default:
+		return &codedError{code: exitPartial, err: fmt.Errorf("部分失败: %d/%d 个链接", s.failed, s.failed+s.success+s.deleted)}
+	}
+}
+
+// summary 输出运行汇总事件
+func (s *runStats) summary() {
+	fields := events.Fields{
+		"command":     s.command,
+		"total":       s.total,
+		"success":     s.success,
+		"failed":      s.failed,
+		"skipped":     s.skipped,
+		"not_started": s.notStarted,
+		"bytes":       s.bytes,
+		"duration_ms": time.Since(s.start).Milliseconds(),
+	}
+	if s.command == "mirror" {
+		fields["deleted"] = s.deleted
+	}
+	events.Emit("run_summary", fields)
+}
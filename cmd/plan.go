@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/difyz9/Link2COS/internal/console"
+	"github.com/difyz9/Link2COS/internal/cos"
+	"github.com/difyz9/Link2COS/internal/events"
+)
+
+// dryRun --dry-run 参数：只探测并输出计划，不下载、不上传，也不写入下载记录
+var dryRun bool
+
+// 计划中的动作
+const (
+	actionTransfer = "transfer"
+	actionSkip     = "skip"
+	actionError    = "error"
+)
+
+// planEntry dry-run 中单个链接的计划
+type planEntry struct {
+	Link        string
+	Destination string // COS 路径或本地保存路径
+	Action      string
+	Reason      string // 跳过的原因
+	Size        int64  // 探测到的大小，-1 表示未知
+	Upload      bool   // 是否上传到COS，决定是否统计分块
+	Note        string // 附加说明，例如将覆盖已存在的对象
+	Err         error
+}
+
+// planStats dry-run 的汇总
+type planStats struct {
+	command     string
+	total       int
+	transfer    int
+	skipped     int
+	failed      int
+	unknownSize int
+	bytes       int64
+	parts       int // 预计的分块总数（不含大小未知的文件）
+	streaming   int // 大小未知、将流式分块上传的文件数
+}
+
+func newPlanStats(command string, total int) *planStats {
+	return &planStats{command: command, total: total}
+}
+
+// add 输出一个链接的计划
+func (p *planStats) add(e planEntry) {
+	fields := events.Fields{"link": e.Link, "action": e.Action}
+	if e.Destination != "" {
+		fields["destination"] = e.Destination
+	}
+
+	if e.Destination != "" {
+		console.Printf("  目标: %s\n", e.Destination)
+	}
+
+	switch e.Action {
+	case actionSkip:
+		p.skipped++
+		fields["reason"] = e.Reason
+		console.Printf("  ⊘ 跳过（%s）\n", skipReasonText(e.Reason))
+	case actionError:
+		p.failed++
+		fields["error"] = e.Err.Error()
+		console.Eprintf("  ✗ 失败: %v\n", e.Err)
+	default:
+		p.transfer++
+		fields["size"] = events.Size(e.Size)
+		if e.Size >= 0 {
+			p.bytes += e.Size
+			console.Printf("  大小: %.2f MB\n", float64(e.Size)/(1024*1024))
+		} else {
+			p.unknownSize++
+			console.Println("  大小: 未知")
+		}
+
+		strategy := "下载"
+		if e.Upload {
+			parts := cos.PartCount(e.Size)
+			fields["parts"] = parts
+			switch {
+			case parts < 0:
+				p.streaming++
+				strategy = "流式分块上传（分块数未知）"
+			case parts == 0:
+				strategy = "一次上传"
+			default:
+				p.parts += parts
+				strategy = fmt.Sprintf("分块上传 %d 块", parts)
+			}
+		}
+		if e.Note != "" {
+			fields["note"] = e.Note
+			strategy += "，" + e.Note
+		}
+		console.Printf("  → 传输（%s）\n", strategy)
+	}
+	events.Emit("plan", fields)
+}
+
+// finish 输出计划汇总；有链接探测失败时按失败数返回退出码
+func (p *planStats) finish() error {
+	console.Printf("\n计划: 传输 %d 个（%.2f MB", p.transfer, float64(p.bytes)/(1024*1024))
+	if p.unknownSize > 0 {
+		console.Printf("，另有 %d 个大小未知", p.unknownSize)
+	}
+	console.Printf("），跳过 %d 个，失败 %d 个\n", p.skipped, p.failed)
+	if p.command != "download" && p.command != "pull" {
+		console.Printf("预计分块: %d 块", p.parts)
+		if p.streaming > 0 {
+			console.Printf("（另有 %d 个文件大小未知，将流式分块上传）", p.streaming)
+		}
+		console.Println()
+	}
+	console.Println("（dry-run：未下载、未上传，也未写入下载记录）")
+
+	events.Emit("plan_summary", events.Fields{
+		"command":      p.command,
+		"total":        p.total,
+		"transfer":     p.transfer,
+		"skipped":      p.skipped,
+		"failed":       p.failed,
+		"bytes":        p.bytes,
+		"unknown_size": p.unknownSize,
+		"parts":        p.parts,
+		"streaming":    p.streaming,
+	})
+
+	switch {
+	case p.failed == 0:
+		return nil
+	case p.transfer == 0 && p.skipped == 0:
+		return &codedError{code: exitAllFailed, err: fmt.Errorf("全部探测失败: %d 个链接", p.failed)}
+	default:
+		return &codedError{code: exitPartial, err: fmt.Errorf("部分探测失败: %d/%d 个链接", p.failed, p.total)}
+	}
+}
+
+// skipReasonText 跳过原因的说明
+func skipReasonText(reason string) string {
+	switch reason {
+	case skipAlreadyDownloaded:
+		return "已下载"
+	case skipObjectExists:
+		return "对象已存在且大小相同"
+	case skipLocalExists:
+		return "本地文件已存在且内容相同"
+	}
+	return reason
+}
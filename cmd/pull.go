@@ -0,0 +1,346 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/difyz9/Link2COS/config"
+	"github.com/difyz9/Link2COS/internal/console"
+	"github.com/difyz9/Link2COS/internal/constants"
+	"github.com/difyz9/Link2COS/internal/cos"
+	"github.com/difyz9/Link2COS/internal/download"
+	"github.com/difyz9/Link2COS/internal/progress"
+	"github.com/difyz9/Link2COS/internal/ratelimit"
+	"github.com/difyz9/Link2COS/internal/util"
+	"github.com/spf13/cobra"
+	cosSDK "github.com/tencentyun/cos-go-sdk-v5"
+)
+
+var pullConfigFile string
+
+// pullCmd represents the pull command
+var pullCmd = &cobra.Command{
+	Use:   "pull cos://<前缀>/ <本地目录>",
+	Short: "从COS下载前缀下的对象到本地目录",
+	Long: `下载COS前缀下的所有对象，对象相对前缀的路径作为本地目录下的路径。
+每个对象用多个并发的 Range 请求下载，中断后再次运行会从已完成的分段继续；
+下载完成后校验 CRC64，一致才保存为目标文件。本地已有内容相同的文件时跳过。
+
+前缀不以 / 结尾且下面没有对象时，按单个对象下载，例如 cos://models/config.json。`,
+	Args: cobra.ExactArgs(2),
+	RunE: runPull,
+}
+
+func init() {
+	rootCmd.AddCommand(pullCmd)
+	pullCmd.Flags().StringVarP(&pullConfigFile, "config", "c", constants.DefaultConfigFile, "配置文件路径（默认: config.yaml）")
+	pullCmd.Flags().BoolVar(&dryRun, "dry-run", false, "只输出要下载的对象，不下载")
+	pullCmd.Flags().StringArrayVar(&uploadInclude, "include", nil, "只下载匹配的对象，例如 *.safetensors，可重复指定")
+	pullCmd.Flags().StringArrayVar(&uploadExclude, "exclude", nil, "排除匹配的对象和目录，例如 **/*.tmp，可重复指定")
+	pullCmd.Flags().IntVarP(&uploadConcurrency, "concurrency", "j", 2, "同时下载的对象数（每个对象另有多个并发的分段请求）")
+	addReportFlag(pullCmd)
+}
+
+// pullJob 一个要下载的对象
+type pullJob struct {
+	index     int
+	key       string
+	rel       string // 相对前缀的路径
+	localPath string
+	modified  time.Time // 对象的最后修改时间
+	err       error     // 无法确定本地路径
+}
+
+// pullOutcome 一个对象的下载结果
+type pullOutcome struct {
+	job      pullJob
+	result   *linkResult
+	err      error
+	duration time.Duration
+}
+
+func runPull(cmd *cobra.Command, args []string) error {
+	prefix, err := cos.ParseURL(args[0])
+	if err != nil {
+		return configError(err)
+	}
+	dest := args[1]
+	if info, err := os.Stat(dest); err == nil && !info.IsDir() {
+		return configError(fmt.Errorf("不是目录: %s", dest))
+	}
+	for _, patterns := range [][]string{uploadInclude, uploadExclude} {
+		if err := util.CheckGlobs(patterns); err != nil {
+			return configError(err)
+		}
+	}
+	if uploadConcurrency < 1 {
+		return configError(fmt.Errorf("--concurrency 必须大于 0"))
+	}
+
+	cfg, err := config.LoadConfig(pullConfigFile, loadOptions(config.ForPull))
+	if err != nil {
+		return configError(fmt.Errorf("加载配置失败: %w", err))
+	}
+	limits, err := ratelimit.NewLimits(cfg.Bandwidth)
+	if err != nil {
+		return configError(err)
+	}
+	defer limits.Stop()
+	client, err := cos.InitClient(cfg, limits)
+	if err != nil {
+		return fmt.Errorf("初始化COS客户端失败: %w", err)
+	}
+	httpClient, err := cos.NewDownloadClient(cfg, limits)
+	if err != nil {
+		return fmt.Errorf("初始化COS客户端失败: %w", err)
+	}
+
+	rc := newRunContext()
+	defer rc.release()
+
+	objects, err := listPullObjects(rc.work, client, prefix)
+	if err != nil {
+		return err
+	}
+	if len(objects) == 0 {
+		return fmt.Errorf("COS 中没有找到对象: %s", args[0])
+	}
+
+	opts := util.WalkOptions{Include: uploadInclude, Exclude: uploadExclude}
+	var jobs []pullJob
+	var total int64
+	excluded := 0
+	for _, obj := range objects {
+		if !opts.Selected(obj.rel) {
+			excluded++
+			continue
+		}
+		localPath, err := pullLocalPath(dest, obj.rel)
+		jobs = append(jobs, pullJob{
+			index:     len(jobs) + 1,
+			key:       obj.Key,
+			rel:       obj.rel,
+			localPath: localPath,
+			modified:  obj.LastModified,
+			err:       err,
+		})
+		total += obj.Size
+	}
+
+	console.Printf("COS 前缀 %q 下共 %d 个对象（%.2f MB）", prefix, len(jobs), float64(total)/(1024*1024))
+	if excluded > 0 {
+		console.Printf("，已过滤 %d 个", excluded)
+	}
+	console.Println()
+	console.Printf("本地目录: %s\n", dest)
+
+	if dryRun {
+		return planPull(rc.work, client, jobs)
+	}
+
+	downloader := download.NewDownloader(httpClient, dest)
+	stats := newRunStats("pull", len(jobs))
+	transferPullJobs(rc, client, downloader, jobs, stats)
+
+	console.Printf("\n完成: 成功 %d, 失败 %d, 跳过 %d\n", stats.success, stats.failed, stats.skipped)
+	if stats.notStarted > 0 {
+		console.Printf("已中断: %d 个对象未开始\n", stats.notStarted)
+	}
+	return stats.finish()
+}
+
+// pullObject 列举得到的对象及其相对前缀的路径
+type pullObject struct {
+	cos.ListedObject
+	rel string
+}
+
+// listPullObjects 列出前缀下的对象；前缀不以 / 结尾时先按目录列举，没有对象再按单个对象查询
+func listPullObjects(ctx context.Context, client *cosSDK.Client, prefix string) ([]pullObject, error) {
+	dir := dirKeyPrefix(prefix)
+	listed, err := cos.ListObjects(ctx, client, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	objects := make([]pullObject, len(listed))
+	for i, obj := range listed {
+		objects[i] = pullObject{ListedObject: obj, rel: strings.TrimPrefix(obj.Key, dir)}
+	}
+	if len(objects) > 0 || dir == prefix {
+		return objects, nil
+	}
+
+	info, err := cos.StatObject(ctx, client, prefix)
+	if err != nil {
+		return nil, fmt.Errorf("查询对象失败: %w", err)
+	}
+	if info == nil {
+		return nil, nil
+	}
+	return []pullObject{{ListedObject: cos.ListedObject{Key: prefix, Size: info.Size}, rel: path.Base(prefix)}}, nil
+}
+
+// transferPullJobs 按 --concurrency 并发下载，结果计入 stats；收到中断信号后不再开始新的对象
+func transferPullJobs(rc *runContext, client *cosSDK.Client, downloader *download.Downloader, jobs []pullJob, stats *runStats) {
+	prog := progress.New(len(jobs))
+	defer prog.Stop()
+	downloader.SetProgress(prog)
+
+	queue := make(chan pullJob)
+	outcomes := make(chan pullOutcome)
+	var wg sync.WaitGroup
+	for w := 0; w < uploadConcurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range queue {
+				stats.linkStarted(job.index, job.key)
+				console.Printf("[%d/%d] 下载: %s → %s\n", job.index, len(jobs), job.key, job.localPath)
+				start := time.Now()
+				result, err := pullFile(rc.work, client, downloader, job)
+				outcomes <- pullOutcome{job: job, result: result, err: err, duration: time.Since(start)}
+			}
+		}()
+	}
+
+	// 未开始的对象在 queue 关闭前写入，读取时所有协程都已退出
+	var notStarted []string
+	go func() {
+		defer close(queue)
+		for i, job := range jobs {
+			select {
+			case queue <- job:
+			case <-rc.stop.Done():
+				for _, rest := range jobs[i:] {
+					notStarted = append(notStarted, rest.key)
+				}
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(outcomes)
+	}()
+
+	for o := range outcomes {
+		prog.LinkDone()
+		switch {
+		case o.err != nil:
+			console.Eprintf("  ✗ 失败: %s: %v\n", o.job.rel, o.err)
+			stats.linkFailed(o.job.key, o.err, o.duration)
+		case o.result.Skipped:
+			console.Printf("  ⊘ 跳过: %s（本地文件已存在且内容相同）\n", o.job.rel)
+			stats.linkSkipped(o.job.key, skipLocalExists)
+		default:
+			console.Printf("  ✓ 成功: %s\n", o.job.rel)
+			o.result.Duration = o.duration
+			stats.linkDone(o.result)
+		}
+	}
+
+	prog.Stop()
+	if len(notStarted) > 0 {
+		stats.interrupt(notStarted)
+	}
+}
+
+// pullFile 下载一个对象，本地已有内容相同的文件时跳过
+func pullFile(ctx context.Context, client *cosSDK.Client, downloader *download.Downloader, job pullJob) (*linkResult, error) {
+	if job.err != nil {
+		return nil, job.err
+	}
+	info, err := cos.StatObject(ctx, client, job.key)
+	if err != nil {
+		return nil, fmt.Errorf("查询对象失败: %w", err)
+	}
+	if info == nil {
+		return nil, fmt.Errorf("对象不存在: %s", job.key)
+	}
+	if sameLocalFile(job.localPath, info, job.modified) {
+		return &linkResult{Link: job.key, Destination: job.localPath, Size: info.Size, Skipped: true}, nil
+	}
+
+	result, err := downloader.DownloadRanged(ctx, download.RangedFile{
+		Link:      client.Object.GetObjectURL(job.key).String(),
+		LocalPath: job.localPath,
+		Size:      info.Size,
+		Version:   info.ETag,
+		CRC64:     info.CRC64,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 本地文件的修改时间设为对象的修改时间，再次 pull 时不必重新计算校验值
+	if mtime := objectMtime(info, job.modified); !mtime.IsZero() {
+		if err := os.Chtimes(job.localPath, mtime, mtime); err != nil {
+			console.Eprintf("  警告: 设置修改时间失败: %v\n", err)
+		}
+	}
+	return &linkResult{Link: job.key, Destination: job.localPath, Size: result.Size, Checksums: result.Checksums}, nil
+}
+
+// planPull dry-run：查询每个对象和本地文件，输出下载计划
+func planPull(ctx context.Context, client *cosSDK.Client, jobs []pullJob) error {
+	plan := newPlanStats("pull", len(jobs))
+	for _, job := range jobs {
+		console.Printf("[%d/%d] %s\n", job.index, len(jobs), job.key)
+		entry := planEntry{Link: job.key, Destination: job.localPath, Action: actionTransfer}
+		info, err := cos.StatObject(ctx, client, job.key)
+		switch {
+		case job.err != nil:
+			entry.Action, entry.Err = actionError, job.err
+		case err != nil:
+			entry.Action, entry.Err = actionError, fmt.Errorf("查询对象失败: %w", err)
+		case info == nil:
+			entry.Action, entry.Err = actionError, fmt.Errorf("对象不存在: %s", job.key)
+		case sameLocalFile(job.localPath, info, job.modified):
+			entry.Action, entry.Reason = actionSkip, skipLocalExists
+		default:
+			entry.Size = info.Size
+			if _, err := os.Stat(job.localPath); err == nil {
+				entry.Note = "将覆盖本地文件"
+			}
+		}
+		plan.add(entry)
+	}
+	return plan.finish()
+}
+
+// sameLocalFile 本地文件是否与对象相同：大小相同，且修改时间与对象一致或 CRC64 相同
+func sameLocalFile(localPath string, info *cos.ObjectInfo, modified time.Time) bool {
+	local, err := os.Stat(localPath)
+	if err != nil || !local.Mode().IsRegular() || local.Size() != info.Size {
+		return false
+	}
+	if mtime := objectMtime(info, modified); !mtime.IsZero() && local.ModTime().Unix() == mtime.Unix() {
+		return true
+	}
+	return sameCRC64(localPath, info)
+}
+
+// objectMtime 对象对应的修改时间：上传时记录的本地修改时间，没有时为对象的最后修改时间
+func objectMtime(info *cos.ObjectInfo, modified time.Time) time.Time {
+	if !info.Mtime.IsZero() {
+		return info.Mtime
+	}
+	return modified
+}
+
+// pullLocalPath 对象在本地的保存路径，拒绝包含 .. 等会跳出本地目录的路径
+func pullLocalPath(dest, rel string) (string, error) {
+	for _, seg := range strings.Split(rel, "/") {
+		if seg == "" || seg == "." || seg == ".." || strings.Contains(seg, `\`) {
+			return "", fmt.Errorf("对象路径包含非法片段: %s", rel)
+		}
+	}
+	return filepath.Join(dest, filepath.FromSlash(rel)), nil
+}
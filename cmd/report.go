@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// reportFile --report 参数：运行结束后写入每个链接结果的文件
+var reportFile string
+
+// 链接的处理状态
+const (
+	statusSuccess = "success"
+	statusFailed  = "failed"
+	statusSkipped = "skipped"
+	statusDeleted = "deleted" // mirror --delete 删除的对象
+
+	statusNotStarted  = "not_started" // 收到中断信号后未开始
+	statusInterrupted = "interrupted" // 传输被第二次中断信号中止
+)
+
+// 跳过的原因
+const (
+	skipAlreadyDownloaded = "already_downloaded" // 下载记录中已有该链接
+	skipObjectExists      = "object_exists"      // COS 中已有大小相同的对象（--skip-existing）
+	skipUnchanged         = "unchanged"          // mirror: 本地文件与对象相同
+	skipLocalExists       = "local_exists"       // pull: 本地已有内容相同的文件
+)
+
+// linkReport 报告中单个链接的结果
+type linkReport struct {
+	Link        string `json:"link"`
+	Status      string `json:"status"`
+	Size        int64  `json:"size"`
+	DurationMs  int64  `json:"duration_ms"`
+	Throughput  int64  `json:"throughput"` // 字节/秒
+	Destination string `json:"destination,omitempty"`
+	Error       string `json:"error,omitempty"` // 失败原因；跳过时为跳过原因
+}
+
+// runReport JSON 报告
+type runReport struct {
+	Command    string       `json:"command"`
+	StartedAt  string       `json:"started_at"`
+	FinishedAt string       `json:"finished_at"`
+	DurationMs int64        `json:"duration_ms"`
+	Total      int          `json:"total"`
+	Success    int          `json:"success"`
+	Failed     int          `json:"failed"`
+	Skipped    int          `json:"skipped"`
+	Deleted    int          `json:"deleted,omitempty"`
+	NotStarted int          `json:"not_started"`
+	Bytes      int64        `json:"bytes"`
+	Links      []linkReport `json:"links"`
+}
+
+// addReportFlag 为命令添加 --report 参数
+func addReportFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&reportFile, "report", "", "运行结束后将每个链接的结果写入文件，按扩展名选择格式: .json 或 .csv")
+}
+
+// checkReportPath 检查报告文件的扩展名，未指定时不检查
+func checkReportPath(path string) error {
+	if path == "" {
+		return nil
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json", ".csv":
+		return nil
+	}
+	return fmt.Errorf("不支持的报告格式: %s（扩展名需为 .json 或 .csv）", path)
+}
+
+// writeReport 按扩展名写入 JSON 或 CSV 报告
+func writeReport(path string, s *runStats) error {
+	var data []byte
+	var err error
+	if strings.ToLower(filepath.Ext(path)) == ".csv" {
+		data, err = csvReport(s)
+	} else {
+		data, err = jsonReport(s)
+	}
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+func jsonReport(s *runStats) ([]byte, error) {
+	now := time.Now()
+	report := runReport{
+		Command:    s.command,
+		StartedAt:  s.start.Format(time.RFC3339),
+		FinishedAt: now.Format(time.RFC3339),
+		DurationMs: now.Sub(s.start).Milliseconds(),
+		Total:      s.total,
+		Success:    s.success,
+		Failed:     s.failed,
+		Skipped:    s.skipped,
+		Deleted:    s.deleted,
+		NotStarted: s.notStarted,
+		Bytes:      s.bytes,
+		Links:      s.results,
+	}
+	if report.Links == nil {
+		report.Links = []linkReport{}
+	}
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+func csvReport(s *runStats) ([]byte, error) {
+	var b strings.Builder
+	w := csv.NewWriter(&b)
+	w.Write([]string{"link", "status", "size", "duration_ms", "throughput", "destination", "error"})
+	for _, r := range s.results {
+		w.Write([]string{
+			r.Link,
+			r.Status,
+			strconv.FormatInt(r.Size, 10),
+			strconv.FormatInt(r.DurationMs, 10),
+			strconv.FormatInt(r.Throughput, 10),
+			r.Destination,
+			r.Error,
+		})
+	}
+	w.Flush()
+	return []byte(b.String()), w.Error()
+}
+
+// throughput 平均速度（字节/秒）
+func throughput(size int64, d time.Duration) int64 {
+	if d <= 0 {
+		return 0
+	}
+	return int64(float64(size) / d.Seconds())
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/difyz9/Link2COS/config"
+	"github.com/difyz9/Link2COS/internal/events"
 	"github.com/spf13/cobra"
 )
 
@@ -12,12 +14,52 @@ var rootCmd = &cobra.Command{
 	Use:   "link2cos",
 	Short: "下载链接文件并上传到腾讯云COS",
 	Long:  `从输入文件中读取链接，下载文件并上传到腾讯云COS存储桶。`,
+
+	PersistentPreRunE: setupOutput,
+}
+
+var (
+	secretID     string
+	secretKey    string
+	sessionToken string
+	profileName  string
+	limitRate    string
+	outputFormat string
+)
+
+func init() {
+	// 参数解析错误按配置错误退出
+	rootCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return configError(err)
+	})
+	rootCmd.PersistentFlags().StringVar(&secretID, "secret-id", "", "COS SecretId（优先于环境变量和配置文件）")
+	rootCmd.PersistentFlags().StringVar(&secretKey, "secret-key", "", "COS SecretKey（优先于环境变量和配置文件）")
+	rootCmd.PersistentFlags().StringVar(&profileName, "profile", "", "使用配置文件中的命名 profile（也可用环境变量 LINK2COS_PROFILE 指定）")
+	rootCmd.PersistentFlags().StringVar(&sessionToken, "session-token", "", "临时密钥的 SessionToken（与 --secret-id/--secret-key 一起使用）")
+	rootCmd.PersistentFlags().StringVar(&outputFormat, "format", outputText, "输出格式: text 或 json（json 时 stdout 逐行输出事件，其他信息输出到 stderr）")
+	// --output 是 --format 的别名；download 命令中 -o/--output 仍是下载目录，会覆盖这里的定义
+	rootCmd.PersistentFlags().StringVar(&outputFormat, "output", outputText, "同 --format")
+	rootCmd.PersistentFlags().MarkHidden("output")
+	rootCmd.PersistentFlags().StringVar(&limitRate, "limit-rate", "", "下载和上传合计的带宽上限，例如 20M、512K（覆盖配置 bandwidth.total，也优先于按时段的限速）")
+}
+
+// loadOptions 汇总影响配置加载的全局参数，require 为当前命令对配置的要求
+func loadOptions(require config.Requirement) config.LoadOptions {
+	return config.LoadOptions{
+		Require:      require,
+		SecretID:     secretID,
+		SecretKey:    secretKey,
+		SessionToken: sessionToken,
+		Profile:      profileName,
+		LimitRate:    limitRate,
+	}
 }
 
 // Execute 执行命令
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
+		events.Emit("error", events.Fields{"error": err.Error()})
 		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
-		os.Exit(1)
+		os.Exit(exitCode(err))
 	}
 }
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/difyz9/Link2COS/internal/console"
+)
+
+// forceExitTimeout 第二次收到信号后等待清理（中止分块上传）的最长时间
+const forceExitTimeout = 30 * time.Second
+
+// runContext 一次运行的取消状态
+// 第一次收到 SIGINT/SIGTERM 时 stop 被取消：不再开始新的链接，正在进行的传输继续完成；
+// 第二次收到信号时 work 被取消：正在进行的下载和上传中止，未完成的分块上传被清理后退出
+type runContext struct {
+	stop context.Context
+	work context.Context
+
+	cancelStop context.CancelFunc
+	cancelWork context.CancelFunc
+	signals    chan os.Signal
+	done       chan struct{}
+}
+
+// newRunContext 开始监听中断信号，运行结束后需要调用 release
+func newRunContext() *runContext {
+	rc := &runContext{
+		signals: make(chan os.Signal, 2),
+		done:    make(chan struct{}),
+	}
+	rc.stop, rc.cancelStop = context.WithCancel(context.Background())
+	rc.work, rc.cancelWork = context.WithCancel(context.Background())
+
+	signal.Notify(rc.signals, os.Interrupt, syscall.SIGTERM)
+	go rc.watch()
+	return rc
+}
+
+func (rc *runContext) watch() {
+	for count := 1; ; count++ {
+		select {
+		case <-rc.done:
+			return
+		case <-rc.signals:
+		}
+
+		switch count {
+		case 1:
+			console.Eprintf("\n收到中断信号：不再开始新的链接，等待正在进行的传输完成（再次按 Ctrl-C 强制退出）\n")
+			rc.cancelStop()
+		case 2:
+			console.Eprintf("\n再次收到中断信号：中止正在进行的传输并清理未完成的分块上传\n")
+			rc.cancelWork()
+			// 清理本身卡住时不再等待
+			go func() {
+				time.Sleep(forceExitTimeout)
+				os.Exit(exitInterrupted)
+			}()
+		default:
+			os.Exit(exitInterrupted)
+		}
+	}
+}
+
+// stopped 是否已收到中断信号，不应再开始新的链接
+func (rc *runContext) stopped() bool {
+	return rc.stop.Err() != nil
+}
+
+// interruptedError 收到中断信号后提前结束的错误，退出码 130
+func interruptedError() error {
+	return &codedError{code: exitInterrupted, err: errors.New("已中断")}
+}
+
+// release 停止监听信号
+func (rc *runContext) release() {
+	signal.Stop(rc.signals)
+	close(rc.done)
+	rc.cancelStop()
+	rc.cancelWork()
+}
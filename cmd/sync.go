@@ -1,14 +1,18 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
-	"os"
-	"strings"
+	"net/http"
+	"time"
 
 	"github.com/difyz9/Link2COS/config"
+	"github.com/difyz9/Link2COS/internal/console"
 	"github.com/difyz9/Link2COS/internal/constants"
 	"github.com/difyz9/Link2COS/internal/cos"
 	"github.com/difyz9/Link2COS/internal/download"
+	"github.com/difyz9/Link2COS/internal/progress"
+	"github.com/difyz9/Link2COS/internal/ratelimit"
 	"github.com/difyz9/Link2COS/internal/tracker"
 	"github.com/difyz9/Link2COS/internal/util"
 	"github.com/spf13/cobra"
@@ -16,8 +20,9 @@ import (
 )
 
 var (
-	syncInputFile  string
-	syncConfigFile string
+	syncInputFile    string
+	syncConfigFile   string
+	syncSkipExisting bool
 )
 
 // syncCmd represents the sync command
@@ -32,104 +37,246 @@ func init() {
 	rootCmd.AddCommand(syncCmd)
 	syncCmd.Flags().StringVarP(&syncInputFile, "input", "i", "", "输入文件路径（必填）")
 	syncCmd.Flags().StringVarP(&syncConfigFile, "config", "c", constants.DefaultConfigFile, "配置文件路径（默认: config.yaml）")
+	syncCmd.Flags().BoolVar(&syncSkipExisting, "skip-existing", false, "COS 中已有大小相同的对象时跳过上传")
+	syncCmd.Flags().BoolVar(&dryRun, "dry-run", false, "只探测链接并输出计划（目标路径、大小、分块数），不下载、不上传，也不写入下载记录")
+	addReportFlag(syncCmd)
 	syncCmd.MarkFlagRequired("input")
 }
 
 func runSync(cmd *cobra.Command, args []string) error {
 	// 加载配置
-	cfg, err := config.LoadConfig(syncConfigFile)
+	cfg, err := config.LoadConfig(syncConfigFile, loadOptions(config.ForSync))
 	if err != nil {
-		return fmt.Errorf("加载配置失败: %w", err)
+		return configError(fmt.Errorf("加载配置失败: %w", err))
 	}
 
+	// 带宽限制由所有链接的下载和上传共享
+	limits, err := ratelimit.NewLimits(cfg.Bandwidth)
+	if err != nil {
+		return configError(err)
+	}
+	defer limits.Stop()
+
 	// 初始化COS客户端
-	cosClient, err := cos.InitClient(cfg)
+	cosClient, err := cos.InitClient(cfg, limits)
 	if err != nil {
 		return fmt.Errorf("初始化COS客户端失败: %w", err)
 	}
 
+	// 创建HTTP客户端（用于下载）
+	httpClient, err := download.CreateHTTPClient(cfg, limits)
+	if err != nil {
+		return configError(err)
+	}
+
 	// 初始化链接跟踪器
 	linkTracker, err := tracker.NewLinkTracker(constants.DownloadedLinksFile)
 	if err != nil {
 		return fmt.Errorf("初始化链接跟踪器失败: %w", err)
 	}
-	fmt.Printf("已下载链接数: %d\n", linkTracker.GetDownloadedCount())
+	console.Printf("已下载链接数: %d\n", linkTracker.GetDownloadedCount())
 
 	// 读取输入文件中的链接
 	links, err := util.ReadLinksFromFile(syncInputFile)
 	if err != nil {
-		return fmt.Errorf("读取输入文件失败: %w", err)
+		return configError(fmt.Errorf("读取输入文件失败: %w", err))
+	}
+
+	console.Printf("共找到 %d 个链接\n", len(links))
+
+	rc := newRunContext()
+	defer rc.release()
+
+	if dryRun {
+		return planSync(rc, cosClient, httpClient, cfg, links, linkTracker)
 	}
 
-	fmt.Printf("共找到 %d 个链接\n", len(links))
+	// 进度显示：终端上为进度条，否则定期输出
+	prog := progress.New(len(links))
+	defer prog.Stop()
 
-	// 处理每个链接
-	successCount := 0
-	failCount := 0
-	skipCount := 0
+	// 处理每个链接，收到中断信号后不再开始新的链接
+	stats := newRunStats("sync", len(links))
 	for i, link := range links {
-		fmt.Printf("[%d/%d] 处理: %s\n", i+1, len(links), link)
+		if rc.stopped() {
+			stats.interrupt(links[i:])
+			break
+		}
+		console.Printf("[%d/%d] 处理: %s\n", i+1, len(links), link)
+		stats.linkStarted(i+1, link)
 
 		// 检查链接是否已下载
 		if linkTracker.IsDownloaded(link) {
-			fmt.Println("  ⊘ 跳过（已下载）")
-			skipCount++
+			console.Println("  ⊘ 跳过（已下载）")
+			stats.linkSkipped(link, skipAlreadyDownloaded)
+			prog.LinkDone()
 			continue
 		}
 
-		if err := processLink(cosClient, cfg, link, linkTracker); err != nil {
-			fmt.Fprintf(os.Stderr, "  ✗ 失败: %v\n", err)
-			failCount++
+		start := time.Now()
+		result, err := processLink(rc.work, cosClient, httpClient, prog, cfg, link, linkTracker)
+		prog.LinkDone()
+		if err != nil {
+			console.Eprintf("  ✗ 失败: %v\n", err)
+			stats.linkFailed(link, err, time.Since(start))
+		} else if result.Skipped {
+			console.Printf("  ⊘ 跳过（%s）\n", skipReasonText(skipObjectExists))
+			stats.linkSkipped(link, skipObjectExists)
 		} else {
-			fmt.Println("  ✓ 成功")
-			successCount++
+			console.Println("  ✓ 成功")
+			result.Duration = time.Since(start)
+			stats.linkDone(result)
 		}
 	}
 
-	fmt.Printf("\n完成: 成功 %d, 失败 %d, 跳过 %d\n", successCount, failCount, skipCount)
-	return nil
+	prog.Stop()
+	console.Printf("\n完成: 成功 %d, 失败 %d, 跳过 %d\n", stats.success, stats.failed, stats.skipped)
+	if stats.notStarted > 0 {
+		console.Printf("已中断: %d 个链接未开始\n", stats.notStarted)
+	}
+	return stats.finish()
 }
 
-// processLink 处理单个链接：下载并上传到COS
-func processLink(client *cosSDK.Client, cfg *config.Config, link string, linkTracker *tracker.LinkTracker) error {
+// processLink 处理单个链接：下载并上传到COS，大小不符或连接中断时自动重试
+func processLink(ctx context.Context, client *cosSDK.Client, httpClient *http.Client, prog *progress.Progress, cfg *config.Config, link string, linkTracker *tracker.LinkTracker) (*linkResult, error) {
 	// 计算COS存储路径
 	cosPath, err := getCOSPath(cfg.COS.URLPrefix, link)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	// 创建HTTP客户端和下载器
-	httpClient := download.CreateHTTPClient(cfg)
+	// COS 中已有大小相同的对象时不再下载，避免为跳过的链接打开下载连接
+	var result *linkResult
+	if syncSkipExisting {
+		if size, same := sameSizeObject(ctx, client, httpClient, link, cosPath, linkTracker); same {
+			result = &linkResult{Link: link, Destination: cosPath, Size: size, Skipped: true}
+		}
+	}
+
+	for attempt := 1; result == nil; attempt++ {
+		result, err = transferLink(ctx, client, httpClient, prog, link, cosPath)
+		if err == nil || !download.IsRetryable(err) || attempt >= constants.MaxDownloadAttempts {
+			break
+		}
+
+		console.Printf("  重试 (%d/%d): %v\n", attempt, constants.MaxDownloadAttempts-1, err)
+		if sleepErr := util.Sleep(ctx, time.Duration(attempt)*constants.RetryBackoff); sleepErr != nil {
+			err = sleepErr
+			break
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// 上传成功且大小校验通过后，记录该链接
+	if err := linkTracker.MarkDownloaded(tracker.Entry{Link: link, Destination: cosPath, Size: result.Size}); err != nil {
+		console.Eprintf("  警告: 记录链接失败: %v\n", err)
+	}
+
+	return result, nil
+}
+
+// transferLink 下载链接并上传到COS，返回文件大小和校验值
+func transferLink(ctx context.Context, client *cosSDK.Client, httpClient *http.Client, prog *progress.Progress, link, cosPath string) (*linkResult, error) {
 	downloader := download.NewDownloader(httpClient, "")
+	downloader.SetProgress(prog)
 
 	// 下载文件（用于上传）
-	reader, fileSize, err := downloader.DownloadForUpload(link)
+	stream, err := downloader.DownloadForUpload(ctx, link)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer reader.Close()
+	defer stream.Close()
 
-	// 使用统一的上传器
+	// 使用统一的上传器（大小未知时流式分块上传）
 	uploader := cos.NewUploader(client)
-	if err := uploader.UploadFromReader(reader, cosPath, fileSize); err != nil {
-		return fmt.Errorf("上传失败: %w", err)
+	uploader.SetProgress(stream.Progress)
+	if err := uploader.UploadFromReader(ctx, stream, cosPath, stream.Size); err != nil {
+		return nil, fmt.Errorf("上传失败: %w", err)
+	}
+
+	return &linkResult{
+		Link:        link,
+		Destination: cosPath,
+		Size:        stream.BytesRead(),
+		Checksums:   stream.Checksums(),
+	}, nil
+}
+
+// sameSizeObject 下载之前判断COS中是否已有与源文件大小相同的对象
+// 先查询对象，存在时再取源文件大小（下载记录或 HEAD 探测）；无法确定时返回 false，照常下载
+func sameSizeObject(ctx context.Context, client *cosSDK.Client, httpClient *http.Client, link, cosPath string, linkTracker *tracker.LinkTracker) (int64, bool) {
+	size, exists, err := cos.HeadObject(ctx, client, cosPath)
+	if err != nil {
+		console.Eprintf("  警告: 查询对象失败，继续上传: %v\n", err)
+		return 0, false
+	}
+	if !exists {
+		return 0, false
 	}
 
-	// 上传成功后，记录该链接
-	if err := linkTracker.MarkDownloaded(link); err != nil {
-		fmt.Fprintf(os.Stderr, "  警告: 记录链接失败: %v\n", err)
+	if entry, ok := linkTracker.Get(link); ok && entry.Size >= 0 {
+		return size, entry.Size == size
+	}
+	probe, err := download.NewDownloader(httpClient, "").Probe(ctx, link)
+	if err != nil {
+		console.Eprintf("  警告: 探测源文件大小失败，继续上传: %v\n", err)
+		return 0, false
 	}
+	return size, probe.Size >= 0 && probe.Size == size
+}
 
-	return nil
+// planSync dry-run：探测每个链接的大小，查询目标对象是否已存在，输出计划
+func planSync(rc *runContext, client *cosSDK.Client, httpClient *http.Client, cfg *config.Config, links []string, linkTracker *tracker.LinkTracker) error {
+	downloader := download.NewDownloader(httpClient, "")
+	plan := newPlanStats("sync", len(links))
+	for i, link := range links {
+		if rc.stopped() {
+			break
+		}
+		console.Printf("[%d/%d] %s\n", i+1, len(links), link)
+		plan.add(planSyncLink(rc.work, client, downloader, cfg, link, linkTracker))
+	}
+	return plan.finish()
 }
 
-// getCOSPath 根据URL前缀计算COS存储路径
-func getCOSPath(prefix, link string) (string, error) {
-	if !strings.HasPrefix(link, prefix) {
-		return "", fmt.Errorf("链接不匹配配置的前缀")
+// planSyncLink 单个链接的计划
+func planSyncLink(ctx context.Context, client *cosSDK.Client, downloader *download.Downloader, cfg *config.Config, link string, linkTracker *tracker.LinkTracker) planEntry {
+	entry := planEntry{Link: link, Action: actionTransfer, Upload: true}
+
+	cosPath, err := getCOSPath(cfg.COS.URLPrefix, link)
+	if err != nil {
+		entry.Action, entry.Err = actionError, err
+		return entry
 	}
+	entry.Destination = cosPath
 
-	// 移除前缀，得到相对路径
-	relativePath := strings.TrimPrefix(link, prefix)
-	return relativePath, nil
+	if linkTracker.IsDownloaded(link) {
+		entry.Action, entry.Reason = actionSkip, skipAlreadyDownloaded
+		return entry
+	}
+
+	probe, err := downloader.Probe(ctx, link)
+	if err != nil {
+		entry.Action, entry.Err = actionError, err
+		return entry
+	}
+	entry.Size = probe.Size
+
+	size, exists, err := cos.HeadObject(ctx, client, cosPath)
+	switch {
+	case err != nil:
+		entry.Note = fmt.Sprintf("查询对象失败: %v", err)
+	case exists && syncSkipExisting && size == probe.Size:
+		entry.Action, entry.Reason = actionSkip, skipObjectExists
+	case exists:
+		entry.Note = fmt.Sprintf("将覆盖已存在的对象（%.2f MB）", float64(size)/(1024*1024))
+	}
+	return entry
+}
+
+// getCOSPath 根据URL前缀计算COS存储路径
+func getCOSPath(prefix, link string) (string, error) {
+	return util.RelativeLinkPath(prefix, link)
 }
@@ -4,59 +4,89 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/difyz9/Link2COS/config"
+	"github.com/difyz9/Link2COS/internal/checksum"
+	"github.com/difyz9/Link2COS/internal/console"
 	"github.com/difyz9/Link2COS/internal/constants"
 	"github.com/difyz9/Link2COS/internal/cos"
+	"github.com/difyz9/Link2COS/internal/events"
+	"github.com/difyz9/Link2COS/internal/progress"
+	"github.com/difyz9/Link2COS/internal/ratelimit"
 	"github.com/spf13/cobra"
+	cosSDK "github.com/tencentyun/cos-go-sdk-v5"
 )
 
 var (
-	localFile   string
-	remotePath  string
+	localFile    string
+	remotePath   string
 	uploadConfig string
 )
 
 // uploadCmd represents the upload command
 var uploadCmd = &cobra.Command{
 	Use:   "upload",
-	Short: "上传本地文件到腾讯云COS",
-	Long:  `直接上传本地文件到腾讯云COS存储桶，可选择指定COS路径。`,
-	RunE:  runLocalUpload,
+	Short: "上传本地文件或目录到腾讯云COS",
+	Long: `直接上传本地文件到腾讯云COS存储桶，可选择指定COS路径。
+使用 -r 递归上传目录，目录中的相对路径作为COS路径的后缀，可用 --include/--exclude 过滤文件。`,
+	RunE: runLocalUpload,
 }
 
 func init() {
 	rootCmd.AddCommand(uploadCmd)
-	uploadCmd.Flags().StringVarP(&localFile, "file", "f", "", "本地文件路径（必填）")
-	uploadCmd.Flags().StringVarP(&remotePath, "path", "p", "", "COS存储路径（可选，默认使用文件名）")
+	uploadCmd.Flags().StringVarP(&localFile, "file", "f", "", "本地文件路径（与 -r 二选一）")
+	uploadCmd.Flags().StringVarP(&uploadDir, "recursive", "r", "", "递归上传的本地目录（与 -f 二选一）")
+	uploadCmd.Flags().StringVarP(&remotePath, "path", "p", "", "COS存储路径（可选，默认使用文件名）；-r 时为COS路径前缀，默认存储桶根目录")
+	uploadCmd.Flags().StringArrayVar(&uploadInclude, "include", nil, "-r 时只上传匹配的文件，例如 *.safetensors，可重复指定")
+	uploadCmd.Flags().StringArrayVar(&uploadExclude, "exclude", nil, "-r 时排除匹配的文件和目录，例如 .git 或 **/*.tmp，可重复指定")
+	uploadCmd.Flags().StringVar(&uploadSymlinks, "symlinks", "skip", "-r 时符号链接的处理方式: skip、follow 或 error")
+	uploadCmd.Flags().IntVarP(&uploadConcurrency, "concurrency", "j", 4, "-r 时同时上传的文件数")
+	uploadCmd.Flags().BoolVar(&uploadSkipExisting, "skip-existing", false, "COS 中已有内容相同的对象时跳过（比较大小，对象带有 CRC64 时也比较校验值）")
 	uploadCmd.Flags().StringVarP(&uploadConfig, "config", "c", constants.DefaultConfigFile, "配置文件路径（默认: config.yaml）")
-	uploadCmd.MarkFlagRequired("file")
+	uploadCmd.Flags().BoolVar(&dryRun, "dry-run", false, "只输出计划（COS路径、大小、分块数），不上传")
+	addReportFlag(uploadCmd)
 }
 
 func runLocalUpload(cmd *cobra.Command, args []string) error {
-	// 检查本地文件是否存在
-	if _, err := os.Stat(localFile); os.IsNotExist(err) {
-		return fmt.Errorf("本地文件不存在: %s", localFile)
+	// 检查本地文件或目录是否存在
+	if err := checkUploadSource(); err != nil {
+		return err
 	}
 
 	// 加载配置
-	cfg, err := config.LoadConfig(uploadConfig)
+	cfg, err := config.LoadConfig(uploadConfig, loadOptions(config.ForUpload))
 	if err != nil {
-		return fmt.Errorf("加载配置失败: %w", err)
+		return configError(fmt.Errorf("加载配置失败: %w", err))
 	}
 
+	// 带宽限制
+	limits, err := ratelimit.NewLimits(cfg.Bandwidth)
+	if err != nil {
+		return configError(err)
+	}
+	defer limits.Stop()
+
 	// 初始化COS客户端
-	cosClient, err := cos.InitClient(cfg)
+	cosClient, err := cos.InitClient(cfg, limits)
 	if err != nil {
 		return fmt.Errorf("初始化COS客户端失败: %w", err)
 	}
 
+	// 第一次中断信号等待当前上传完成，第二次中止上传并清理分块；查询对象的请求同样可以被中止
+	rc := newRunContext()
+	defer rc.release()
+
+	if uploadDir != "" {
+		return runDirUpload(rc, cosClient)
+	}
+
 	// 确定COS路径
 	cosPath := remotePath
 	if cosPath == "" {
 		// 如果没有指定路径，使用文件名
 		cosPath = filepath.Base(localFile)
-		fmt.Printf("未指定COS路径，使用文件名: %s\n", cosPath)
+		console.Printf("未指定COS路径，使用文件名: %s\n", cosPath)
 	}
 
 	// 获取文件信息
@@ -65,16 +95,67 @@ func runLocalUpload(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("获取文件信息失败: %w", err)
 	}
 
-	fmt.Printf("本地文件: %s\n", localFile)
-	fmt.Printf("文件大小: %.2f MB\n", float64(fileInfo.Size())/(1024*1024))
-	fmt.Printf("COS路径: %s\n", cosPath)
+	console.Printf("本地文件: %s\n", localFile)
+	console.Printf("文件大小: %.2f MB\n", float64(fileInfo.Size())/(1024*1024))
+	console.Printf("COS路径: %s\n", cosPath)
+
+	if dryRun {
+		return planUpload(rc, cosClient, fileInfo.Size(), cosPath)
+	}
+
+	stats := newRunStats("upload", 1)
+	stats.linkStarted(1, localFile)
+
+	if uploadSkipExisting {
+		same, err := sameAsObject(rc.work, cosClient, localFile, fileInfo.Size(), cosPath)
+		if err != nil {
+			console.Eprintf("警告: 查询对象失败，继续上传: %v\n", err)
+		} else if same {
+			console.Println("⊘ 跳过（对象已存在且内容相同）")
+			stats.linkSkipped(localFile, skipObjectExists)
+			return stats.finish()
+		}
+	}
+	events.Emit("size_known", events.Fields{"link": localFile, "size": fileInfo.Size()})
+
+	// 进度显示
+	prog := progress.New(1)
+	defer prog.Stop()
+	file := prog.Start(localFile, filepath.Base(localFile), fileInfo.Size(), progress.Upload)
 
 	// 使用统一的上传器
+	start := time.Now()
 	uploader := cos.NewUploader(cosClient)
-	if err := uploader.UploadFile(localFile, cosPath); err != nil {
-		return fmt.Errorf("上传失败: %w", err)
+	uploader.SetProgress(file)
+	uploader.SetMetadata(cos.FileMetadata(fileInfo))
+	err = uploader.UploadFile(rc.work, localFile, cosPath)
+	file.Close()
+	prog.Stop()
+	if err != nil {
+		stats.linkFailed(localFile, err, time.Since(start))
+		stats.finish()
+		code := exitAllFailed
+		if rc.work.Err() != nil {
+			code = exitInterrupted
+		}
+		return &codedError{code: code, err: fmt.Errorf("上传失败: %w", err)}
+	}
+
+	console.Println("✓ 上传成功")
+	result := &linkResult{Link: localFile, Destination: cosPath, Size: fileInfo.Size(), Duration: time.Since(start)}
+	// 校验值需要重新读取文件，只在输出事件时计算
+	if events.Enabled() {
+		if result.Checksums, err = checksum.File(localFile); err != nil {
+			console.Eprintf("  警告: 计算校验值失败: %v\n", err)
+		}
 	}
+	stats.linkDone(result)
+	return stats.finish()
+}
 
-	fmt.Println("✓ 上传成功")
-	return nil
+// planUpload dry-run：查询目标对象是否已存在，输出上传计划
+func planUpload(rc *runContext, client *cosSDK.Client, size int64, cosPath string) error {
+	plan := newPlanStats("upload", 1)
+	plan.add(planUploadEntry(rc.work, client, localFile, size, cosPath))
+	return plan.finish()
 }
@@ -0,0 +1,280 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/difyz9/Link2COS/internal/checksum"
+	"github.com/difyz9/Link2COS/internal/console"
+	"github.com/difyz9/Link2COS/internal/cos"
+	"github.com/difyz9/Link2COS/internal/events"
+	"github.com/difyz9/Link2COS/internal/progress"
+	"github.com/difyz9/Link2COS/internal/util"
+	cosSDK "github.com/tencentyun/cos-go-sdk-v5"
+)
+
+var (
+	uploadDir          string
+	uploadInclude      []string
+	uploadExclude      []string
+	uploadSymlinks     string
+	uploadConcurrency  int
+	uploadSkipExisting bool
+)
+
+// dirUploadJob 目录中的一个文件及其COS路径
+type dirUploadJob struct {
+	index int
+	file  util.LocalFile
+	key   string
+}
+
+// dirUploadOutcome 一个文件的上传结果
+type dirUploadOutcome struct {
+	job      dirUploadJob
+	result   *linkResult
+	err      error
+	duration time.Duration
+}
+
+// runDirUpload 递归上传目录，相对路径作为COS路径的后缀
+func runDirUpload(rc *runContext, client *cosSDK.Client) error {
+	policy, err := util.ParseSymlinkPolicy(uploadSymlinks)
+	if err != nil {
+		return configError(err)
+	}
+	for _, patterns := range [][]string{uploadInclude, uploadExclude} {
+		if err := util.CheckGlobs(patterns); err != nil {
+			return configError(err)
+		}
+	}
+	if uploadConcurrency < 1 {
+		return configError(fmt.Errorf("--concurrency 必须大于 0"))
+	}
+
+	walked, err := util.WalkFiles(uploadDir, util.WalkOptions{
+		Include:  uploadInclude,
+		Exclude:  uploadExclude,
+		Symlinks: policy,
+	})
+	if err != nil {
+		return fmt.Errorf("遍历目录失败: %w", err)
+	}
+	for _, link := range walked.Symlinks {
+		console.Printf("  ⊘ 跳过符号链接: %s\n", link)
+	}
+
+	var total int64
+	for _, f := range walked.Files {
+		total += f.Size
+	}
+	prefix := dirKeyPrefix(remotePath)
+	console.Printf("本地目录: %s\n", uploadDir)
+	console.Printf("共 %d 个文件（%.2f MB）", len(walked.Files), float64(total)/(1024*1024))
+	if walked.Excluded > 0 {
+		console.Printf("，已过滤 %d 个", walked.Excluded)
+	}
+	console.Println()
+	if prefix != "" {
+		console.Printf("COS路径前缀: %s\n", prefix)
+	}
+
+	jobs := make([]dirUploadJob, len(walked.Files))
+	for i, f := range walked.Files {
+		jobs[i] = dirUploadJob{index: i + 1, file: f, key: prefix + f.Rel}
+	}
+
+	if dryRun {
+		return planDirUpload(rc, client, jobs)
+	}
+	return uploadDirJobs(rc, client, jobs)
+}
+
+// uploadDirJobs 并发上传目录中的文件并输出汇总
+func uploadDirJobs(rc *runContext, client *cosSDK.Client, jobs []dirUploadJob) error {
+	stats := newRunStats("upload", len(jobs))
+	transferDirJobs(rc, client, jobs, uploadSkipExisting, stats)
+
+	console.Printf("\n完成: 成功 %d, 失败 %d, 跳过 %d\n", stats.success, stats.failed, stats.skipped)
+	if stats.notStarted > 0 {
+		console.Printf("已中断: %d 个文件未开始\n", stats.notStarted)
+	}
+	return stats.finish()
+}
+
+// transferDirJobs 按 --concurrency 并发上传，结果计入 stats；收到中断信号后不再开始新的文件
+func transferDirJobs(rc *runContext, client *cosSDK.Client, jobs []dirUploadJob, skipExisting bool, stats *runStats) {
+	prog := progress.New(len(jobs))
+	defer prog.Stop()
+
+	queue := make(chan dirUploadJob)
+	outcomes := make(chan dirUploadOutcome)
+	var wg sync.WaitGroup
+	for w := 0; w < uploadConcurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range queue {
+				stats.linkStarted(job.index, job.file.Path)
+				console.Printf("[%d/%d] 上传: %s → %s\n", job.index, len(jobs), job.file.Rel, job.key)
+				start := time.Now()
+				result, err := uploadDirFile(rc.work, client, prog, job, skipExisting)
+				outcomes <- dirUploadOutcome{job: job, result: result, err: err, duration: time.Since(start)}
+			}
+		}()
+	}
+
+	// 未开始的文件在 queue 关闭前写入，读取时所有协程都已退出
+	var notStarted []string
+	go func() {
+		defer close(queue)
+		for i, job := range jobs {
+			select {
+			case queue <- job:
+			case <-rc.stop.Done():
+				for _, rest := range jobs[i:] {
+					notStarted = append(notStarted, rest.file.Path)
+				}
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(outcomes)
+	}()
+
+	for o := range outcomes {
+		prog.LinkDone()
+		switch {
+		case o.err != nil:
+			console.Eprintf("  ✗ 失败: %s: %v\n", o.job.file.Rel, o.err)
+			stats.linkFailed(o.job.file.Path, o.err, o.duration)
+		case o.result.Skipped:
+			console.Printf("  ⊘ 跳过: %s（对象已存在且内容相同）\n", o.job.file.Rel)
+			stats.linkSkipped(o.job.file.Path, skipObjectExists)
+		default:
+			console.Printf("  ✓ 成功: %s\n", o.job.file.Rel)
+			o.result.Duration = o.duration
+			stats.linkDone(o.result)
+		}
+	}
+
+	prog.Stop()
+	if len(notStarted) > 0 {
+		stats.interrupt(notStarted)
+	}
+}
+
+// uploadDirFile 上传目录中的一个文件，skipExisting 时跳过内容相同的对象
+func uploadDirFile(ctx context.Context, client *cosSDK.Client, prog *progress.Progress, job dirUploadJob, skipExisting bool) (*linkResult, error) {
+	if skipExisting {
+		same, err := sameAsObject(ctx, client, job.file.Path, job.file.Size, job.key)
+		if err != nil {
+			console.Eprintf("  警告: 查询对象失败，继续上传: %s: %v\n", job.key, err)
+		} else if same {
+			return &linkResult{Link: job.file.Path, Destination: job.key, Size: job.file.Size, Skipped: true}, nil
+		}
+	}
+
+	file := prog.Start(job.file.Path, job.file.Rel, job.file.Size, progress.Upload)
+	uploader := cos.NewUploader(client)
+	uploader.SetProgress(file)
+	uploader.SetMetadata(cos.FileMetadata(job.file.Info))
+	err := uploader.UploadFile(ctx, job.file.Path, job.key)
+	file.Close()
+	if err != nil {
+		return nil, fmt.Errorf("上传失败: %w", err)
+	}
+
+	result := &linkResult{Link: job.file.Path, Destination: job.key, Size: job.file.Size}
+	// 校验值需要重新读取文件，只在输出事件时计算
+	if events.Enabled() {
+		if result.Checksums, err = checksum.File(job.file.Path); err != nil {
+			console.Eprintf("  警告: 计算校验值失败: %v\n", err)
+		}
+	}
+	return result, nil
+}
+
+// planDirUpload dry-run：查询每个文件的目标对象，输出上传计划；收到中断信号后不再查询
+func planDirUpload(rc *runContext, client *cosSDK.Client, jobs []dirUploadJob) error {
+	plan := newPlanStats("upload", len(jobs))
+	for _, job := range jobs {
+		if rc.stopped() {
+			break
+		}
+		console.Printf("[%d/%d] %s\n", job.index, len(jobs), job.file.Rel)
+		plan.add(planUploadEntry(rc.work, client, job.file.Path, job.file.Size, job.key))
+	}
+	return plan.finish()
+}
+
+// planUploadEntry 单个本地文件的上传计划
+func planUploadEntry(ctx context.Context, client *cosSDK.Client, localPath string, size int64, key string) planEntry {
+	entry := planEntry{Link: localPath, Destination: key, Action: actionTransfer, Size: size, Upload: true}
+
+	info, err := cos.StatObject(ctx, client, key)
+	switch {
+	case err != nil:
+		entry.Note = fmt.Sprintf("查询对象失败: %v", err)
+	case info == nil:
+	case uploadSkipExisting && info.Size == size && sameCRC64(localPath, info):
+		entry.Action, entry.Reason = actionSkip, skipObjectExists
+	default:
+		entry.Note = fmt.Sprintf("将覆盖已存在的对象（%.2f MB）", float64(info.Size)/(1024*1024))
+	}
+	return entry
+}
+
+// sameAsObject 本地文件是否与COS中的对象相同：大小相同，且对象带有 CRC64 时校验值也相同
+func sameAsObject(ctx context.Context, client *cosSDK.Client, localPath string, size int64, key string) (bool, error) {
+	info, err := cos.StatObject(ctx, client, key)
+	if err != nil || info == nil || info.Size != size {
+		return false, err
+	}
+	return sameCRC64(localPath, info), nil
+}
+
+// sameCRC64 对象没有 CRC64 时只能按大小判断，视为相同；读取本地文件失败时视为不同
+func sameCRC64(localPath string, info *cos.ObjectInfo) bool {
+	if info.CRC64 == "" {
+		return true
+	}
+	sums, err := checksum.File(localPath)
+	return err == nil && sums.CRC64 == info.CRC64
+}
+
+// dirKeyPrefix 目录上传的COS路径前缀，非空时以 / 结尾
+func dirKeyPrefix(p string) string {
+	p = strings.TrimLeft(p, "/")
+	if p != "" && !strings.HasSuffix(p, "/") {
+		p += "/"
+	}
+	return p
+}
+
+// checkUploadSource -f 和 -r 必须且只能指定一个，并检查本地路径是否存在
+func checkUploadSource() error {
+	if (localFile == "") == (uploadDir == "") {
+		return configError(fmt.Errorf("请指定 -f/--file 或 -r/--recursive 其中之一"))
+	}
+	if localFile != "" {
+		if _, err := os.Stat(localFile); os.IsNotExist(err) {
+			return configError(fmt.Errorf("本地文件不存在: %s", localFile))
+		}
+		return nil
+	}
+	info, err := os.Stat(uploadDir)
+	if err != nil {
+		return configError(fmt.Errorf("本地目录不存在: %s", uploadDir))
+	}
+	if !info.IsDir() {
+		return configError(fmt.Errorf("不是目录: %s（上传单个文件请使用 -f）", uploadDir))
+	}
+	return nil
+}
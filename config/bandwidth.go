@@ -0,0 +1,241 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BandwidthConfig 带宽限制，所有链接和分块上传共享同一组限速器
+// 速率可写作 20M、512K、1G 或字节数（按 1024 进位，单位为每秒），0 或不填表示不限速
+type BandwidthConfig struct {
+	Total    string          `yaml:"total"`    // 下载和上传合计
+	Download string          `yaml:"download"` // 下载合计
+	Upload   string          `yaml:"upload"`   // 上传合计
+	Hosts    []HostBandwidth `yaml:"hosts"`    // 按主机限速，按顺序匹配第一条
+
+	Schedule []BandwidthWindow `yaml:"schedule"` // 按时段调整 total/download/upload，运行中到点自动切换
+
+	TotalOverride string `yaml:"-"` // --limit-rate 参数，优先于 total 和各时段的 total
+}
+
+// BandwidthWindow 一个时段内的限速，未填写的项沿用 bandwidth 中的值
+type BandwidthWindow struct {
+	From     string   `yaml:"from"`     // 开始时间 HH:MM（本地时间）
+	To       string   `yaml:"to"`       // 结束时间 HH:MM，早于 from 表示跨越午夜
+	Days     []string `yaml:"days"`     // 生效的星期: mon、tue、wed、thu、fri、sat、sun，为空表示每天；跨午夜时按开始的那天算
+	Total    string   `yaml:"total"`    // 该时段的合计速率，0 表示不限速
+	Download string   `yaml:"download"` // 该时段的下载速率
+	Upload   string   `yaml:"upload"`   // 该时段的上传速率
+}
+
+// weekdays days 中可用的星期缩写
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// parseClock 解析 HH:MM，返回从零点开始的分钟数
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("无效的时间: %s（格式 HH:MM）", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// onDay days 是否包含指定的星期
+func (w *BandwidthWindow) onDay(day time.Weekday) bool {
+	if len(w.Days) == 0 {
+		return true
+	}
+	for _, d := range w.Days {
+		if wd, ok := weekdays[strings.ToLower(strings.TrimSpace(d))]; ok && wd == day {
+			return true
+		}
+	}
+	return false
+}
+
+// Contains 时间 t 是否落在该时段内，from 与 to 相同表示全天
+func (w *BandwidthWindow) Contains(t time.Time) bool {
+	from, err := parseClock(w.From)
+	if err != nil {
+		return false
+	}
+	to, err := parseClock(w.To)
+	if err != nil {
+		return false
+	}
+
+	minute := t.Hour()*60 + t.Minute()
+	switch {
+	case from < to:
+		return minute >= from && minute < to && w.onDay(t.Weekday())
+	case from > to:
+		// 跨午夜：午夜之后的部分属于前一天开始的时段
+		if minute >= from {
+			return w.onDay(t.Weekday())
+		}
+		return minute < to && w.onDay(t.AddDate(0, 0, -1).Weekday())
+	}
+	return w.onDay(t.Weekday())
+}
+
+// RatesAt 返回时间 t 生效的 total、download、upload，取第一个包含 t 的时段，未填写的项沿用 bandwidth 中的值；
+// 指定了 --limit-rate 时 total 始终为该值
+func (b *BandwidthConfig) RatesAt(t time.Time) (total, download, upload string) {
+	total, download, upload = b.Total, b.Download, b.Upload
+	for i := range b.Schedule {
+		w := &b.Schedule[i]
+		if !w.Contains(t) {
+			continue
+		}
+		if w.Total != "" {
+			total = w.Total
+		}
+		if w.Download != "" {
+			download = w.Download
+		}
+		if w.Upload != "" {
+			upload = w.Upload
+		}
+		break
+	}
+	if b.TotalOverride != "" {
+		total = b.TotalOverride
+	}
+	return total, download, upload
+}
+
+// BaseTotal 时段之外的合计速率，指定了 --limit-rate 时为该值
+func (b *BandwidthConfig) BaseTotal() string {
+	if b.TotalOverride != "" {
+		return b.TotalOverride
+	}
+	return b.Total
+}
+
+// HostBandwidth 单个主机的限速
+type HostBandwidth struct {
+	Host string `yaml:"host"` // 实际传输数据的主机（重定向之后），*.example.com 匹配子域名
+	Rate string `yaml:"rate"` // 该主机的速率上限
+}
+
+// IsZero 是否没有任何限速
+func (b *BandwidthConfig) IsZero() bool {
+	return b.BaseTotal() == "" && b.Download == "" && b.Upload == "" && len(b.Hosts) == 0 && len(b.Schedule) == 0
+}
+
+// Describe 返回限速设置的简要说明，例如 "总计 20.00 MB/s, 上传 5.00 MB/s"
+func (b *BandwidthConfig) Describe() string {
+	var parts []string
+	add := func(name, value string) {
+		if rate, err := ParseRate(value); err == nil && rate > 0 {
+			parts = append(parts, name+" "+FormatRate(rate))
+		}
+	}
+	add("总计", b.BaseTotal())
+	add("下载", b.Download)
+	add("上传", b.Upload)
+	for _, h := range b.Hosts {
+		add(h.Host, h.Rate)
+	}
+	if len(b.Schedule) > 0 {
+		parts = append(parts, fmt.Sprintf("%d 个时段", len(b.Schedule)))
+	}
+	if len(parts) == 0 {
+		return "不限速"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ParseRate 解析速率，返回每秒字节数，0 表示不限速
+// 支持 K、M、G 后缀（1024 进位），可带 B、iB 或 /s，例如 20M、1.5MB/s、800KiB
+func ParseRate(s string) (int64, error) {
+	value := strings.TrimSpace(s)
+	if value == "" {
+		return 0, nil
+	}
+
+	upper := strings.ToUpper(value)
+	upper = strings.TrimSuffix(upper, "/S")
+	upper = strings.TrimSuffix(upper, "B")
+	upper = strings.TrimSuffix(upper, "I")
+
+	multiplier := 1.0
+	switch {
+	case strings.HasSuffix(upper, "K"):
+		multiplier = 1 << 10
+	case strings.HasSuffix(upper, "M"):
+		multiplier = 1 << 20
+	case strings.HasSuffix(upper, "G"):
+		multiplier = 1 << 30
+	}
+	if multiplier != 1 {
+		upper = upper[:len(upper)-1]
+	}
+
+	n, err := strconv.ParseFloat(strings.TrimSpace(upper), 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("无效的速率: %s（例如 20M、512K）", s)
+	}
+	return int64(n * multiplier), nil
+}
+
+// FormatRate 将每秒字节数格式化为便于阅读的形式
+func FormatRate(rate int64) string {
+	switch {
+	case rate <= 0:
+		return "不限速"
+	case rate >= 1<<30:
+		return fmt.Sprintf("%.2f GB/s", float64(rate)/(1<<30))
+	case rate >= 1<<20:
+		return fmt.Sprintf("%.2f MB/s", float64(rate)/(1<<20))
+	case rate >= 1<<10:
+		return fmt.Sprintf("%.2f KB/s", float64(rate)/(1<<10))
+	}
+	return fmt.Sprintf("%d B/s", rate)
+}
+
+// validateBandwidth 检查各项速率的格式
+func validateBandwidth(b *BandwidthConfig, addf func(format string, args ...interface{})) {
+	check := func(field, rate string) {
+		if _, err := ParseRate(rate); err != nil {
+			addf("bandwidth.%s %v", field, err)
+		}
+	}
+	check("total", b.Total)
+	if _, err := ParseRate(b.TotalOverride); err != nil {
+		addf("--limit-rate %v", err)
+	}
+	check("download", b.Download)
+	check("upload", b.Upload)
+	for i, h := range b.Hosts {
+		if h.Host == "" {
+			addf("bandwidth.hosts[%d] 缺少 host", i)
+		}
+		if h.Rate == "" {
+			addf("bandwidth.hosts[%d] 缺少 rate", i)
+		}
+		check(fmt.Sprintf("hosts[%d].rate", i), h.Rate)
+	}
+	for i, w := range b.Schedule {
+		field := fmt.Sprintf("schedule[%d]", i)
+		if _, err := parseClock(w.From); err != nil {
+			addf("bandwidth.%s.from %v", field, err)
+		}
+		if _, err := parseClock(w.To); err != nil {
+			addf("bandwidth.%s.to %v", field, err)
+		}
+		for _, d := range w.Days {
+			if _, ok := weekdays[strings.ToLower(strings.TrimSpace(d))]; !ok {
+				addf("bandwidth.%s.days 无效的星期: %s（可选: mon, tue, wed, thu, fri, sat, sun）", field, d)
+			}
+		}
+		check(field+".total", w.Total)
+		check(field+".download", w.Download)
+		check(field+".upload", w.Upload)
+	}
+}
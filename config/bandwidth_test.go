@@ -0,0 +1,147 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRate(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int64
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"0", 0, false},
+		{"512", 512, false},
+		{"512K", 512 << 10, false},
+		{"20M", 20 << 20, false},
+		{"1G", 1 << 30, false},
+		{"20m", 20 << 20, false},
+		{"1.5MB/s", 3 << 19, false},
+		{"800KiB", 800 << 10, false},
+		{"2MiB/s", 2 << 20, false},
+		{" 10 M ", 10 << 20, false},
+		{"100B", 100, false},
+		{"-1M", 0, true},
+		{"fast", 0, true},
+		{"M", 0, true},
+		{"10T", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseRate(tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseRate(%q) = %d，应返回错误", tt.value, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseRate(%q) 返回错误: %v", tt.value, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseRate(%q) = %d，应为 %d", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBandwidthWindowContains(t *testing.T) {
+	// 2024-01-01 是星期一
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2024, 1, day, hour, minute, 0, 0, time.Local)
+	}
+	tests := []struct {
+		name   string
+		window BandwidthWindow
+		t      time.Time
+		want   bool
+	}{
+		{"白天时段内", BandwidthWindow{From: "09:00", To: "18:00"}, at(1, 12, 0), true},
+		{"包含开始时间", BandwidthWindow{From: "09:00", To: "18:00"}, at(1, 9, 0), true},
+		{"不包含结束时间", BandwidthWindow{From: "09:00", To: "18:00"}, at(1, 18, 0), false},
+		{"白天时段之前", BandwidthWindow{From: "09:00", To: "18:00"}, at(1, 8, 59), false},
+		{"跨午夜的前半段", BandwidthWindow{From: "22:00", To: "06:00"}, at(1, 23, 30), true},
+		{"跨午夜的后半段", BandwidthWindow{From: "22:00", To: "06:00"}, at(2, 3, 0), true},
+		{"跨午夜包含零点", BandwidthWindow{From: "22:00", To: "06:00"}, at(2, 0, 0), true},
+		{"跨午夜不包含结束时间", BandwidthWindow{From: "22:00", To: "06:00"}, at(2, 6, 0), false},
+		{"跨午夜的时段之外", BandwidthWindow{From: "22:00", To: "06:00"}, at(1, 12, 0), false},
+		{"开始与结束相同表示全天", BandwidthWindow{From: "00:00", To: "00:00"}, at(1, 15, 0), true},
+		{"限定星期", BandwidthWindow{From: "09:00", To: "18:00", Days: []string{"mon"}}, at(1, 12, 0), true},
+		{"限定星期之外", BandwidthWindow{From: "09:00", To: "18:00", Days: []string{"tue"}}, at(1, 12, 0), false},
+		{"星期大小写不敏感", BandwidthWindow{From: "09:00", To: "18:00", Days: []string{" Mon "}}, at(1, 12, 0), true},
+		{"跨午夜按开始的那天算", BandwidthWindow{From: "22:00", To: "06:00", Days: []string{"fri"}}, at(6, 2, 0), true},
+		{"跨午夜后半段不按当天算", BandwidthWindow{From: "22:00", To: "06:00", Days: []string{"sat"}}, at(6, 2, 0), false},
+		{"跨午夜前半段按当天算", BandwidthWindow{From: "22:00", To: "06:00", Days: []string{"fri"}}, at(5, 23, 0), true},
+		{"星期一凌晨属于星期日开始的时段", BandwidthWindow{From: "22:00", To: "06:00", Days: []string{"sun"}}, at(1, 1, 0), true},
+		{"无效的时间", BandwidthWindow{From: "9am", To: "18:00"}, at(1, 12, 0), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.window.Contains(tt.t); got != tt.want {
+				t.Errorf("%s-%s %v 在 %s 的结果为 %v，应为 %v",
+					tt.window.From, tt.window.To, tt.window.Days, tt.t.Format("Mon 15:04"), got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRatesAt(t *testing.T) {
+	b := BandwidthConfig{
+		Total:    "20M",
+		Download: "10M",
+		Schedule: []BandwidthWindow{
+			{From: "22:00", To: "06:00", Total: "0"},
+			{From: "00:00", To: "12:00", Upload: "1M"},
+		},
+	}
+	tests := []struct {
+		name                        string
+		t                           time.Time
+		wantTotal, wantDown, wantUp string
+	}{
+		{"时段之外沿用默认值", time.Date(2024, 1, 1, 15, 0, 0, 0, time.Local), "20M", "10M", ""},
+		{"跨午夜时段覆盖 total", time.Date(2024, 1, 1, 23, 0, 0, 0, time.Local), "0", "10M", ""},
+		{"取第一个匹配的时段", time.Date(2024, 1, 2, 3, 0, 0, 0, time.Local), "0", "10M", ""},
+		{"第二个时段", time.Date(2024, 1, 2, 9, 0, 0, 0, time.Local), "20M", "10M", "1M"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			total, down, up := b.RatesAt(tt.t)
+			if total != tt.wantTotal || down != tt.wantDown || up != tt.wantUp {
+				t.Errorf("RatesAt = %q/%q/%q，应为 %q/%q/%q", total, down, up, tt.wantTotal, tt.wantDown, tt.wantUp)
+			}
+		})
+	}
+}
+
+func TestRatesAtLimitRateOverride(t *testing.T) {
+	b := BandwidthConfig{
+		Total:         "20M",
+		TotalOverride: "5M",
+		Schedule: []BandwidthWindow{
+			{From: "22:00", To: "06:00", Total: "0", Upload: "1M"},
+		},
+	}
+	tests := []struct {
+		name      string
+		t         time.Time
+		wantTotal string
+		wantUp    string
+	}{
+		{"时段之外", time.Date(2024, 1, 1, 15, 0, 0, 0, time.Local), "5M", ""},
+		{"时段内仍使用 --limit-rate", time.Date(2024, 1, 1, 23, 0, 0, 0, time.Local), "5M", "1M"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			total, _, up := b.RatesAt(tt.t)
+			if total != tt.wantTotal || up != tt.wantUp {
+				t.Errorf("RatesAt = %q/%q，应为 %q/%q", total, up, tt.wantTotal, tt.wantUp)
+			}
+		})
+	}
+	if got := b.BaseTotal(); got != "5M" {
+		t.Errorf("BaseTotal = %q，应为 5M", got)
+	}
+}
@@ -2,60 +2,235 @@ package config
 
 import (
 	"fmt"
-	"os"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/difyz9/Link2COS/internal/console"
 
 	"gopkg.in/yaml.v3"
 )
 
 // Config 配置文件结构
 type Config struct {
-	COS COSConfig `yaml:"cos"`
+	COS       COSConfig       `yaml:"cos"`
+	Download  DownloadConfig  `yaml:"download"`
+	Network   NetworkConfig   `yaml:"network"`
+	Sources   SourcesConfig   `yaml:"sources"`
+	Bandwidth BandwidthConfig `yaml:"bandwidth"`
+
+	Profiles map[string]yaml.Node `yaml:"profiles"` // 命名 profile，未写的字段继承顶层配置
+	Profile  string               `yaml:"-"`        // 当前使用的 profile，空表示顶层配置
 }
 
 // COSConfig 腾讯云COS配置
 type COSConfig struct {
-	SecretID   string `yaml:"secret_id"`
-	SecretKey  string `yaml:"secret_key"`
+	SecretID   string `yaml:"secret_id"`   // 可使用 ${ENV} 插值，留空时从环境变量或共享凭证文件读取
+	SecretKey  string `yaml:"secret_key"`  // 同上
 	BucketName string `yaml:"bucket_name"` // 例如: examplebucket-1250000000
 	Region     string `yaml:"region"`      // 例如: ap-guangzhou
 	BucketURL  string `yaml:"-"`           // 自动拼接: https://bucketname.cos.region.myqcloud.com
+	Endpoint   string `yaml:"endpoint"`    // 可选，自定义访问域名，例如全球加速域名，设置后替代自动拼接的 BucketURL
 	URLPrefix  string `yaml:"url_prefix"`  // 例如: https://huggingface.co/Comfy-Org/Wan_2.2_ComfyUI_Repackaged/resolve/main/
-	Proxy      string `yaml:"proxy"`       // HTTP/HTTPS代理，例如: http://127.0.0.1:7890
+	Proxy      string `yaml:"proxy"`       // 已废弃，等同于 network.source_proxy
+
+	SessionToken       string                   `yaml:"session_token"`       // 临时密钥的 token，与 secret_id/secret_key 一起使用
+	CredentialsFile    string                   `yaml:"credentials_file"`    // 共享凭证文件，默认 ~/.tencentcloud/credentials
+	CredentialsProfile string                   `yaml:"credentials_profile"` // 共享凭证文件中的 profile，默认 default
+	CredentialProvider CredentialProviderConfig `yaml:"credential_provider"` // 自动刷新的临时凭证来源
+	CredentialSource   string                   `yaml:"-"`                   // 实际使用的凭证来源，用于提示
 }
 
-// LoadConfig 从文件加载配置
-func LoadConfig(configPath string) (*Config, error) {
-	data, err := os.ReadFile(configPath)
-	if err != nil {
-		return nil, fmt.Errorf("读取配置文件失败: %w", err)
+// CredentialProviderConfig 临时凭证提供者配置
+type CredentialProviderConfig struct {
+	Type          string            `yaml:"type"`           // static（默认）、http 或 command
+	URL           string            `yaml:"url"`            // http: 返回 STS 格式 JSON 的端点
+	Headers       map[string]string `yaml:"headers"`        // http: 请求端点时附带的请求头
+	Command       []string          `yaml:"command"`        // command: 外部命令及参数，标准输出为 STS 格式 JSON
+	RefreshBefore time.Duration     `yaml:"refresh_before"` // 过期前多久刷新，默认 5m
+}
+
+// bucketURL 返回存储桶的访问地址
+func (c *COSConfig) bucketURL() string {
+	if c.Endpoint != "" {
+		return strings.TrimRight(c.Endpoint, "/")
+	}
+	if c.BucketName == "" || c.Region == "" {
+		return ""
 	}
+	return fmt.Sprintf("https://%s.cos.%s.myqcloud.com", c.BucketName, c.Region)
+}
+
+// UsesTemporaryCredentials 是否由凭证提供者动态获取密钥
+func (c *COSConfig) UsesTemporaryCredentials() bool {
+	t := strings.ToLower(c.CredentialProvider.Type)
+	return t != "" && t != "static"
+}
+
+// DownloadConfig 本地下载配置
+type DownloadConfig struct {
+	Layout     string `yaml:"layout"`      // 本地目录布局: flat（仅文件名，默认）或 mirror（保留相对 url_prefix 的路径）
+	OnConflict string `yaml:"on_conflict"` // 本地路径冲突策略: error（默认）、suffix 或 overwrite
+}
+
+// NetworkConfig 网络配置：下载源和存储后端分别使用的代理，以及按主机的规则
+type NetworkConfig struct {
+	SourceProxy  string      `yaml:"source_proxy"`  // 下载源使用的代理，支持 http://、https://、socks5://、socks5h://
+	StorageProxy string      `yaml:"storage_proxy"` // 访问COS使用的代理，默认直连
+	NoProxy      string      `yaml:"no_proxy"`      // 直连的主机列表，逗号分隔，为空时读取环境变量 NO_PROXY
+	Rules        []ProxyRule `yaml:"rules"`         // 按主机的规则，按顺序匹配，优先于 no_proxy 和默认代理
+
+	SourceTLS  TLSConfig `yaml:"source_tls"`  // 访问下载源的 TLS 设置
+	StorageTLS TLSConfig `yaml:"storage_tls"` // 访问COS的 TLS 设置
+
+	Timeouts TimeoutsConfig `yaml:"timeouts"` // 各阶段超时和停滞检测，下载源和COS共用
+}
+
+// TLSConfig TLS 设置，留空时使用系统默认
+type TLSConfig struct {
+	CAFiles    []string `yaml:"ca_files"`    // 额外信任的 CA 证书（PEM），与系统证书一起使用，例如企业代理的根证书
+	ClientCert string   `yaml:"client_cert"` // 双向 TLS 的客户端证书（PEM）
+	ClientKey  string   `yaml:"client_key"`  // 客户端证书的私钥（PEM）
+	MinVersion string   `yaml:"min_version"` // 最低 TLS 版本: 1.0、1.1、1.2 或 1.3，默认 1.2
+	Pins       []TLSPin `yaml:"pins"`        // 公钥固定，证书链中需有一个证书的公钥匹配
+}
+
+// TLSPin 主机的公钥固定
+type TLSPin struct {
+	Host   string   `yaml:"host"`   // 主机名，*.example.com 匹配子域名，为空表示所有主机
+	SHA256 []string `yaml:"sha256"` // 证书公钥（SPKI）的 SHA-256，Base64 编码，可带 sha256/ 前缀
+}
+
+// IsZero 是否没有任何 TLS 设置
+func (t *TLSConfig) IsZero() bool {
+	return len(t.CAFiles) == 0 && t.ClientCert == "" && t.ClientKey == "" && t.MinVersion == "" && len(t.Pins) == 0
+}
+
+// ProxyRule 按主机选择代理的规则
+type ProxyRule struct {
+	Host  string `yaml:"host"`  // 主机名，例如 huggingface.co；*.example.com 或 .example.com 匹配子域名
+	Proxy string `yaml:"proxy"` // 代理URL，或 direct 表示直连
+}
+
+// SourcesConfig 下载源的认证和请求头
+type SourcesConfig struct {
+	UserAgent string       `yaml:"user_agent"` // 下载请求的 User-Agent，为空时使用 Go 的默认值
+	Netrc     bool         `yaml:"netrc"`      // 是否读取 .netrc 中的用户名和密码
+	NetrcFile string       `yaml:"netrc_file"` // .netrc 路径，默认读取环境变量 NETRC，否则 ~/.netrc
+	Hosts     []SourceHost `yaml:"hosts"`      // 按主机的认证和请求头，按顺序匹配第一条
+}
+
+// SourceHost 单个下载源主机的认证设置，只发送给匹配的主机，重定向到其他主机时不会携带
+type SourceHost struct {
+	Host           string            `yaml:"host"`             // 主机名，*.example.com 或 .example.com 匹配子域名
+	BearerToken    string            `yaml:"bearer_token"`     // Bearer token，可使用 ${ENV} 插值
+	BearerTokenEnv string            `yaml:"bearer_token_env"` // 从环境变量读取 Bearer token，例如 HF_TOKEN
+	BasicAuth      *BasicAuth        `yaml:"basic_auth"`       // HTTP Basic 认证
+	Headers        map[string]string `yaml:"headers"`          // 附加的请求头
+}
 
-	var config Config
-	if err := yaml.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("解析配置文件失败: %w", err)
+// BasicAuth HTTP Basic 认证的用户名和密码
+type BasicAuth struct {
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+}
+
+// LoadOptions 加载配置时来自命令行的覆盖项
+type LoadOptions struct {
+	SecretID     string
+	SecretKey    string
+	SessionToken string
+	Profile      string      // --profile 参数，为空时读取环境变量 LINK2COS_PROFILE
+	LimitRate    string      // --limit-rate 参数，覆盖 bandwidth.total 和各时段的 total
+	Require      Requirement // 当前命令对配置的要求，例如 ForSync
+}
+
+// Resolve 按优先级解析访问密钥并计算存储桶地址，不校验必填字段
+func (c *Config) Resolve(opts LoadOptions) error {
+	if err := resolveCredentials(&c.COS, opts); err != nil {
+		return err
+	}
+
+	// 根据 BucketName 和 Region 拼接 BucketURL，配置了 endpoint 时以 endpoint 为准
+	c.COS.BucketURL = c.COS.bucketURL()
+
+	c.Bandwidth.TotalOverride = opts.LimitRate
+
+	// 兼容旧配置: cos.proxy 作为下载源代理
+	if c.Network.SourceProxy == "" {
+		c.Network.SourceProxy = c.COS.Proxy
 	}
+	return nil
+}
+
+// RedactURL 隐藏URL中的用户名和密码，用于输出代理等可能带凭证的地址
+func RedactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "（无效的URL）"
+	}
+	if u.User == nil {
+		return u.String()
+	}
+	u.User = nil
+	return strings.Replace(u.String(), "://", "://***@", 1)
+}
 
-	// 验证必填字段
-	if config.COS.SecretID == "" || config.COS.SecretKey == "" {
-		return nil, fmt.Errorf("配置文件缺少必填字段: secret_id 或 secret_key")
+// RedactQuery 隐藏URL中的查询参数、用户名和密码，用于输出重定向后的预签名地址等查询参数中带签名的地址
+func RedactQuery(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "（无效的URL）"
 	}
-	if config.COS.BucketName == "" {
-		return nil, fmt.Errorf("配置文件缺少必填字段: bucket_name")
+	if u.RawQuery != "" {
+		u.RawQuery = "***"
 	}
-	if config.COS.Region == "" {
-		return nil, fmt.Errorf("配置文件缺少必填字段: region")
+	u.Fragment = ""
+	return RedactURL(u.String())
+}
+
+// LoadConfig 从文件加载配置
+func LoadConfig(configPath string, opts LoadOptions) (*Config, error) {
+	config, err := ReadConfig(configPath, ActiveProfile(opts.Profile))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := config.Resolve(opts); err != nil {
+		return nil, err
 	}
-	if config.COS.URLPrefix == "" {
-		return nil, fmt.Errorf("配置文件缺少必填字段: url_prefix")
+
+	// 按命令的要求校验，一次报告所有问题
+	if err := config.Validate(opts.Require); err != nil {
+		return nil, err
 	}
 
-	// 根据 BucketName 和 Region 拼接 BucketURL
-	config.COS.BucketURL = fmt.Sprintf("https://%s.cos.%s.myqcloud.com", config.COS.BucketName, config.COS.Region)
+	if config.Profile != "" {
+		console.Printf("使用 profile: %s\n", config.Profile)
+	}
+	if opts.Require&RequireCOS != 0 {
+		if config.COS.UsesTemporaryCredentials() {
+			config.COS.CredentialSource = "临时凭证提供者 (" + config.COS.CredentialProvider.Type + ")"
+		}
+		console.Printf("凭证来源: %s\n", config.COS.CredentialSource)
+	}
 
-	// 代理配置是可选的
-	if config.COS.Proxy != "" {
-		fmt.Printf("已配置代理: %s\n", config.COS.Proxy)
+	// 代理配置是可选的，输出时隐藏代理的用户名和密码
+	if config.Network.SourceProxy != "" {
+		console.Printf("下载源代理: %s\n", RedactURL(config.Network.SourceProxy))
+	}
+	if config.Network.StorageProxy != "" && opts.Require&RequireCOS != 0 {
+		console.Printf("COS代理: %s\n", RedactURL(config.Network.StorageProxy))
+	}
+	if len(config.Network.Rules) > 0 {
+		console.Printf("代理规则: %d 条\n", len(config.Network.Rules))
+	}
+	if !config.Bandwidth.IsZero() {
+		console.Printf("带宽限制: %s\n", config.Bandwidth.Describe())
+	}
+	if config.Network.Timeouts != (TimeoutsConfig{}) {
+		console.Printf("超时设置: %s\n", config.Network.Timeouts.Describe())
 	}
 
-	return &config, nil
+	return config, nil
 }
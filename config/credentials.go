@@ -0,0 +1,151 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// 凭证相关的环境变量
+const (
+	EnvTencentSecretID     = "TENCENTCLOUD_SECRET_ID"
+	EnvTencentSecretKey    = "TENCENTCLOUD_SECRET_KEY"
+	EnvTencentSessionToken = "TENCENTCLOUD_SESSION_TOKEN"
+	EnvCOSSecretID         = "COS_SECRET_ID"
+	EnvCOSSecretKey        = "COS_SECRET_KEY"
+	EnvCOSSessionToken     = "COS_SESSION_TOKEN"
+	EnvCredentialsFile     = "TENCENTCLOUD_CREDENTIALS_FILE"
+	EnvCredentialProfile   = "TENCENTCLOUD_PROFILE"
+)
+
+// defaultCredentialsFile 共享凭证文件的默认位置（相对用户主目录）
+const defaultCredentialsFile = ".tencentcloud/credentials"
+
+// resolveCredentials 按优先级解析 SecretID/SecretKey：
+// 命令行参数 > 环境变量 > 配置文件（可使用 ${ENV} 插值）> 共享凭证文件
+// 每一级必须同时提供 ID 和 Key，session token 与密钥取自同一级，避免不同来源的密钥混用
+func resolveCredentials(cos *COSConfig, opts LoadOptions) error {
+	type source struct {
+		name  string
+		id    string
+		key   string
+		token string
+	}
+
+	sources := []source{
+		{"命令行参数", opts.SecretID, opts.SecretKey, opts.SessionToken},
+		{"环境变量 " + EnvTencentSecretID, os.Getenv(EnvTencentSecretID), os.Getenv(EnvTencentSecretKey), os.Getenv(EnvTencentSessionToken)},
+		{"环境变量 " + EnvCOSSecretID, os.Getenv(EnvCOSSecretID), os.Getenv(EnvCOSSecretKey), os.Getenv(EnvCOSSessionToken)},
+		{"配置文件", cos.SecretID, cos.SecretKey, cos.SessionToken},
+	}
+
+	for _, s := range sources {
+		if s.id != "" && s.key != "" {
+			cos.SecretID, cos.SecretKey, cos.SessionToken = s.id, s.key, s.token
+			cos.CredentialSource = s.name
+			return nil
+		}
+	}
+
+	// 最后尝试共享凭证文件
+	path, profile := credentialsFileLocation(cos)
+	id, key, token, err := readCredentialsFile(path, profile)
+	if err != nil {
+		return err
+	}
+	if id != "" && key != "" {
+		cos.SecretID, cos.SecretKey, cos.SessionToken = id, key, token
+		cos.CredentialSource = fmt.Sprintf("凭证文件 %s [%s]", path, profile)
+		return nil
+	}
+
+	// 没有找到完整凭证，由调用方决定是否报错
+	cos.SecretID, cos.SecretKey, cos.SessionToken = "", "", ""
+	return nil
+}
+
+// credentialsFileLocation 返回共享凭证文件路径和 profile 名称
+func credentialsFileLocation(cos *COSConfig) (string, string) {
+	path := cos.CredentialsFile
+	if path == "" {
+		path = os.Getenv(EnvCredentialsFile)
+	}
+	if path == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, defaultCredentialsFile)
+		}
+	}
+	if strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, path[2:])
+		}
+	}
+
+	profile := cos.CredentialsProfile
+	if profile == "" {
+		profile = os.Getenv(EnvCredentialProfile)
+	}
+	if profile == "" {
+		profile = "default"
+	}
+
+	return path, profile
+}
+
+// readCredentialsFile 读取 INI 格式的共享凭证文件，文件不存在时返回空凭证
+//
+//	[default]
+//	secret_id = AKIDxxxx
+//	secret_key = xxxx
+//	token = xxxx        # 可选，临时密钥
+func readCredentialsFile(path, profile string) (id, key, token string, err error) {
+	if path == "" {
+		return "", "", "", nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", "", "", nil
+		}
+		return "", "", "", fmt.Errorf("读取凭证文件失败: %w", err)
+	}
+	defer file.Close()
+
+	var section string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+		if section != profile {
+			continue
+		}
+
+		name, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		value = strings.Trim(strings.TrimSpace(value), `"'`)
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "secret_id":
+			id = value
+		case "secret_key":
+			key = value
+		case "token", "session_token":
+			token = value
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", "", "", fmt.Errorf("读取凭证文件失败: %w", err)
+	}
+
+	return id, key, token, nil
+}
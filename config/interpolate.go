@@ -0,0 +1,49 @@
+package config
+
+import (
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// envPattern 匹配 ${NAME} 和 ${NAME:-默认值}
+var envPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// expandEnv 替换字符串中的 ${NAME} 引用，未设置的变量替换为默认值或空字符串
+// 只识别带花括号的形式，值中普通的 $ 字符（例如密码）保持不变；$${NAME} 可转义为字面量 ${NAME}
+func expandEnv(s string) string {
+	if !strings.Contains(s, "${") {
+		return s
+	}
+
+	const escaped = "\x00"
+	s = strings.ReplaceAll(s, "$${", escaped)
+	s = envPattern.ReplaceAllStringFunc(s, func(m string) string {
+		groups := envPattern.FindStringSubmatch(m)
+		if value, ok := os.LookupEnv(groups[1]); ok && value != "" {
+			return value
+		}
+		return groups[3]
+	})
+	return strings.ReplaceAll(s, escaped, "${")
+}
+
+// interpolateNode 对 YAML 树中所有标量值做环境变量插值
+// 在解析后的节点上替换，变量值中的特殊字符不会破坏 YAML 结构
+func interpolateNode(node *yaml.Node) {
+	if node.Kind == yaml.ScalarNode {
+		if expanded := expandEnv(node.Value); expanded != node.Value {
+			node.Value = expanded
+			// 未加引号的值按替换后的内容重新推断类型，例如 ${PORT} -> 整数
+			if node.Style == 0 {
+				node.Tag = ""
+			}
+		}
+		return
+	}
+	for _, child := range node.Content {
+		interpolateNode(child)
+	}
+}
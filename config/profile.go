@@ -0,0 +1,69 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvProfile 选择 profile 的环境变量，--profile 参数优先
+const EnvProfile = "LINK2COS_PROFILE"
+
+// ActiveProfile 返回当前选择的 profile 名称，空字符串表示使用顶层的默认配置
+func ActiveProfile(flagValue string) string {
+	if flagValue != "" {
+		return flagValue
+	}
+	return os.Getenv(EnvProfile)
+}
+
+// ReadConfig 读取并解析配置文件，不解析密钥也不校验必填字段
+// profile 非空时，以顶层配置为默认值，用 profiles.<name> 中出现的字段覆盖
+func ReadConfig(configPath, profile string) (*Config, error) {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("读取配置文件失败: %w", err)
+	}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("解析配置文件失败: %w", err)
+	}
+
+	// 替换 ${ENV} 引用，配置文件中可以不写明文密钥
+	interpolateNode(&root)
+
+	var config Config
+	if err := root.Decode(&config); err != nil {
+		return nil, fmt.Errorf("解析配置文件失败: %w", err)
+	}
+
+	if profile == "" {
+		return &config, nil
+	}
+
+	node, ok := config.Profiles[profile]
+	if !ok {
+		return nil, fmt.Errorf("配置文件中没有 profile: %s（可用: %s）", profile, strings.Join(config.ProfileNames(), ", "))
+	}
+	// 只覆盖 profile 中出现的字段，其余沿用顶层配置
+	if err := node.Decode(&config); err != nil {
+		return nil, fmt.Errorf("解析 profile %s 失败: %w", profile, err)
+	}
+	config.Profile = profile
+
+	return &config, nil
+}
+
+// ProfileNames 返回配置文件中定义的 profile 名称（已排序）
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// TimeoutsConfig 网络各阶段的超时和停滞检测
+// 不设置整体超时：大文件的下载或分块上传可以持续任意长的时间，只要一直有进展
+type TimeoutsConfig struct {
+	Connect        time.Duration `yaml:"connect"`         // 建立 TCP 连接（包括连接代理），默认 30s
+	TLSHandshake   time.Duration `yaml:"tls_handshake"`   // TLS 握手，默认 15s
+	ResponseHeader time.Duration `yaml:"response_header"` // 请求发送完成后等待响应头，默认 60s
+	IdleRead       time.Duration `yaml:"idle_read"`       // 下载或上传中连续没有数据的最长时间，默认 60s
+	StallMinSpeed  string        `yaml:"stall_min_speed"` // 最低平均速度，例如 1K，低于它视为停滞并重试，0 表示不检测，默认 1K
+	StallWindow    time.Duration `yaml:"stall_window"`    // 计算平均速度的时间窗口，默认 60s
+}
+
+// 各项超时的默认值
+const (
+	DefaultConnectTimeout        = 30 * time.Second
+	DefaultTLSHandshakeTimeout   = 15 * time.Second
+	DefaultResponseHeaderTimeout = 60 * time.Second
+	DefaultIdleReadTimeout       = 60 * time.Second
+	DefaultStallMinSpeed         = "1K"
+	DefaultStallWindow           = 60 * time.Second
+)
+
+// WithDefaults 返回填充了默认值的副本
+func (t TimeoutsConfig) WithDefaults() TimeoutsConfig {
+	if t.Connect == 0 {
+		t.Connect = DefaultConnectTimeout
+	}
+	if t.TLSHandshake == 0 {
+		t.TLSHandshake = DefaultTLSHandshakeTimeout
+	}
+	if t.ResponseHeader == 0 {
+		t.ResponseHeader = DefaultResponseHeaderTimeout
+	}
+	if t.IdleRead == 0 {
+		t.IdleRead = DefaultIdleReadTimeout
+	}
+	if t.StallMinSpeed == "" {
+		t.StallMinSpeed = DefaultStallMinSpeed
+	}
+	if t.StallWindow == 0 {
+		t.StallWindow = DefaultStallWindow
+	}
+	return t
+}
+
+// validateTimeouts 检查超时不为负数、最低速度格式正确
+func validateTimeouts(t *TimeoutsConfig, addf func(format string, args ...interface{})) {
+	durations := []struct {
+		field string
+		value time.Duration
+	}{
+		{"connect", t.Connect},
+		{"tls_handshake", t.TLSHandshake},
+		{"response_header", t.ResponseHeader},
+		{"idle_read", t.IdleRead},
+		{"stall_window", t.StallWindow},
+	}
+	for _, d := range durations {
+		if d.value < 0 {
+			addf("network.timeouts.%s 不能为负数: %s", d.field, d.value)
+		}
+	}
+	if _, err := ParseRate(t.StallMinSpeed); err != nil {
+		addf("network.timeouts.stall_min_speed %v", err)
+	}
+}
+
+// Describe 用于输出的说明
+func (t TimeoutsConfig) Describe() string {
+	t = t.WithDefaults()
+	stall := "不检测"
+	if rate, err := ParseRate(t.StallMinSpeed); err == nil && rate > 0 {
+		stall = fmt.Sprintf("%s 内低于 %s", t.StallWindow, FormatRate(rate))
+	}
+	return fmt.Sprintf("连接 %s，TLS 握手 %s，响应头 %s，无数据 %s，停滞: %s",
+		t.Connect, t.TLSHandshake, t.ResponseHeader, t.IdleRead, stall)
+}
@@ -0,0 +1,205 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// Requirement 命令对配置的要求，按位组合
+type Requirement int
+
+const (
+	// RequireCOS 需要访问COS：访问密钥、bucket_name、region
+	RequireCOS Requirement = 1 << iota
+	// RequireURLPrefix 需要 url_prefix 计算COS路径（sync）
+	RequireURLPrefix
+)
+
+// 各命令的配置要求
+const (
+	ForDownload = Requirement(0)
+	ForUpload   = RequireCOS
+	ForPull     = RequireCOS
+	ForList     = RequireCOS
+	ForSync     = RequireCOS | RequireURLPrefix
+)
+
+// ValidationError 配置校验失败，包含发现的全部问题
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	if len(e.Problems) == 1 {
+		return "配置校验失败: " + e.Problems[0]
+	}
+	return "配置校验失败:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// Validate 按命令的要求校验配置，一次返回所有问题而不是遇到第一个就停止
+func (c *Config) Validate(req Requirement) error {
+	var problems []string
+	addf := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if req&RequireCOS != 0 {
+		// 使用凭证提供者时密钥在运行时获取
+		if !c.COS.UsesTemporaryCredentials() && (c.COS.SecretID == "" || c.COS.SecretKey == "") {
+			addf("未找到COS访问密钥: 请通过 --secret-id/--secret-key、环境变量 %s/%s、配置文件 secret_id/secret_key 或 ~/%s 提供",
+				EnvTencentSecretID, EnvTencentSecretKey, defaultCredentialsFile)
+		}
+		if c.COS.BucketName == "" {
+			addf("缺少必填字段: cos.bucket_name")
+		}
+		if c.COS.Region == "" && c.COS.Endpoint == "" {
+			addf("缺少必填字段: cos.region")
+		}
+		if c.COS.Endpoint != "" {
+			if u, err := url.Parse(c.COS.Endpoint); err != nil || u.Scheme == "" || u.Host == "" {
+				addf("cos.endpoint 不是有效的URL: %s", c.COS.Endpoint)
+			}
+		}
+
+		switch strings.ToLower(c.COS.CredentialProvider.Type) {
+		case "", "static":
+		case "http":
+			if c.COS.CredentialProvider.URL == "" {
+				addf("cos.credential_provider.url 不能为空")
+			}
+		case "command":
+			if len(c.COS.CredentialProvider.Command) == 0 {
+				addf("cos.credential_provider.command 不能为空")
+			}
+		default:
+			addf("未知的凭证提供者类型: %s（可选: static, http, command）", c.COS.CredentialProvider.Type)
+		}
+	}
+
+	if req&RequireURLPrefix != 0 && c.COS.URLPrefix == "" {
+		addf("缺少必填字段: cos.url_prefix")
+	}
+
+	// 以下为可选字段，填写了就必须有效
+	checkProxy := func(field, proxy string) {
+		if err := validateProxyURL(proxy); err != nil {
+			addf("%s %v", field, err)
+		}
+	}
+	if c.COS.Proxy != "" {
+		checkProxy("cos.proxy", c.COS.Proxy)
+	}
+	if c.Network.SourceProxy != "" && c.Network.SourceProxy != c.COS.Proxy {
+		checkProxy("network.source_proxy", c.Network.SourceProxy)
+	}
+	if c.Network.StorageProxy != "" {
+		checkProxy("network.storage_proxy", c.Network.StorageProxy)
+	}
+	for i, rule := range c.Network.Rules {
+		if rule.Host == "" {
+			addf("network.rules[%d] 缺少 host", i)
+		}
+		switch {
+		case rule.Proxy == "":
+			addf("network.rules[%d] 缺少 proxy（代理URL或 direct）", i)
+		case !strings.EqualFold(rule.Proxy, "direct"):
+			checkProxy(fmt.Sprintf("network.rules[%d].proxy", i), rule.Proxy)
+		}
+	}
+	validateTLS("network.source_tls", &c.Network.SourceTLS, addf)
+	if req&RequireCOS != 0 {
+		validateTLS("network.storage_tls", &c.Network.StorageTLS, addf)
+	}
+	for i, h := range c.Sources.Hosts {
+		if h.Host == "" {
+			addf("sources.hosts[%d] 缺少 host", i)
+		}
+		auths := 0
+		if h.BearerToken != "" {
+			auths++
+		}
+		if h.BearerTokenEnv != "" {
+			auths++
+			if os.Getenv(h.BearerTokenEnv) == "" {
+				addf("sources.hosts[%d].bearer_token_env: 环境变量 %s 未设置", i, h.BearerTokenEnv)
+			}
+		}
+		if h.BasicAuth != nil {
+			auths++
+			if h.BasicAuth.Username == "" {
+				addf("sources.hosts[%d].basic_auth 缺少 username", i)
+			}
+		}
+		if auths > 1 {
+			addf("sources.hosts[%d] 只能设置 bearer_token、bearer_token_env 和 basic_auth 中的一项", i)
+		}
+	}
+	validateBandwidth(&c.Bandwidth, addf)
+	validateTimeouts(&c.Network.Timeouts, addf)
+	switch strings.ToLower(c.Download.Layout) {
+	case "", "flat", "mirror":
+	default:
+		addf("未知的目录布局 download.layout: %s（可选: flat, mirror）", c.Download.Layout)
+	}
+	switch strings.ToLower(c.Download.OnConflict) {
+	case "", "error", "suffix", "overwrite":
+	default:
+		addf("未知的冲突策略 download.on_conflict: %s（可选: error, suffix, overwrite）", c.Download.OnConflict)
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// validateProxyURL 检查代理URL的格式和协议，错误信息中不包含代理凭证
+func validateProxyURL(proxy string) error {
+	u, err := url.Parse(proxy)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("不是有效的URL")
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+		return nil
+	}
+	return fmt.Errorf("不支持的代理协议: %s（可选: http, https, socks5, socks5h）", u.Scheme)
+}
+
+// validateTLS 检查 TLS 设置中的文件是否存在以及各字段的格式
+func validateTLS(field string, t *TLSConfig, addf func(format string, args ...interface{})) {
+	checkFile := func(name, path string) {
+		if _, err := os.Stat(path); err != nil {
+			addf("%s.%s 无法读取: %s", field, name, path)
+		}
+	}
+	for i, path := range t.CAFiles {
+		checkFile(fmt.Sprintf("ca_files[%d]", i), path)
+	}
+	switch {
+	case t.ClientCert != "" && t.ClientKey != "":
+		checkFile("client_cert", t.ClientCert)
+		checkFile("client_key", t.ClientKey)
+	case t.ClientCert != "" || t.ClientKey != "":
+		addf("%s 的 client_cert 和 client_key 需要同时设置", field)
+	}
+	switch t.MinVersion {
+	case "", "1.0", "1.1", "1.2", "1.3":
+	default:
+		addf("%s.min_version 无效: %s（可选: 1.0, 1.1, 1.2, 1.3）", field, t.MinVersion)
+	}
+	for i, pin := range t.Pins {
+		if len(pin.SHA256) == 0 {
+			addf("%s.pins[%d] 缺少 sha256", field, i)
+		}
+		for _, hash := range pin.SHA256 {
+			if sum, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(hash, "sha256/")); err != nil || len(sum) != sha256.Size {
+				addf("%s.pins[%d] 不是有效的 SHA-256 Base64 值: %s", field, i, hash)
+			}
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package checksum
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"hash/crc64"
+	"io"
+	"os"
+	"strconv"
+)
+
+// crcTable COS 使用的 CRC64 多项式（ECMA-182），与 x-cos-hash-crc64ecma 一致
+var crcTable = crc64.MakeTable(crc64.ECMA)
+
+// Sums 文件内容的校验值
+type Sums struct {
+	SHA256 string // 十六进制
+	CRC64  string // 十进制，与 COS 返回的 x-cos-hash-crc64ecma 格式相同
+}
+
+// Hasher 边写入边计算 SHA-256 和 CRC64
+type Hasher struct {
+	sha hash.Hash
+	crc hash.Hash64
+}
+
+// New 创建 Hasher
+func New() *Hasher {
+	return &Hasher{sha: sha256.New(), crc: crc64.New(crcTable)}
+}
+
+// Write implements io.Writer.
+func (h *Hasher) Write(p []byte) (int, error) {
+	h.sha.Write(p)
+	h.crc.Write(p)
+	return len(p), nil
+}
+
+// Sums 返回当前已写入内容的校验值
+func (h *Hasher) Sums() Sums {
+	return Sums{
+		SHA256: hex.EncodeToString(h.sha.Sum(nil)),
+		CRC64:  strconv.FormatUint(h.crc.Sum64(), 10),
+	}
+}
+
+// File 计算本地文件的校验值
+func File(path string) (Sums, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Sums{}, err
+	}
+	defer f.Close()
+
+	h := New()
+	if _, err := io.Copy(h, f); err != nil {
+		return Sums{}, err
+	}
+	return h.Sums(), nil
+}
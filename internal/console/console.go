@@ -0,0 +1,118 @@
+package console
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// 所有面向用户的输出都经过这里，与终端底部的进度状态行互不覆盖
+var (
+	mu     sync.Mutex
+	out    io.Writer = os.Stdout
+	errOut io.Writer = os.Stderr
+
+	// 状态行绘制在 stderr 上，仅在 stderr 是终端时使用
+	statusFile            = os.Stderr
+	statusOut   io.Writer = statusFile
+	statusTTY             = IsTerminal(statusFile)
+	statusLines []string
+)
+
+// IsTerminal 判断文件是否为终端
+func IsTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
+
+// StatusSupported 是否可以在终端底部绘制状态行
+func StatusSupported() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return statusTTY
+}
+
+// SetOutput 设置普通信息的输出位置，例如 JSON 输出模式下改为 stderr
+func SetOutput(w io.Writer) {
+	mu.Lock()
+	defer mu.Unlock()
+	out = w
+}
+
+// Printf 输出普通信息
+func Printf(format string, args ...interface{}) {
+	write(out, fmt.Sprintf(format, args...))
+}
+
+// Println 输出普通信息并换行
+func Println(args ...interface{}) {
+	write(out, fmt.Sprintln(args...))
+}
+
+// Eprintf 输出错误和警告信息到 stderr
+func Eprintf(format string, args ...interface{}) {
+	write(errOut, fmt.Sprintf(format, args...))
+}
+
+// write 先擦除状态行，输出后再重新绘制，避免信息和进度条混在一行
+func write(w io.Writer, s string) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	clearStatus()
+	io.WriteString(w, s)
+	drawStatus()
+}
+
+// SetStatus 替换终端底部的状态行，非终端时忽略
+// 每行截断到终端宽度：折行后一行会占多行，clearStatus 按行数回退时会留下残影
+func SetStatus(lines []string) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if !statusTTY {
+		return
+	}
+	clearStatus()
+	statusLines = nil
+	if len(lines) > 0 {
+		// 少用一列：写满最后一列时部分终端会提前折行
+		width := terminalWidth(statusFile) - 1
+		statusLines = make([]string, len(lines))
+		for i, line := range lines {
+			statusLines[i] = truncateWidth(line, width)
+		}
+	}
+	drawStatus()
+}
+
+// ClearStatus 擦除并清空状态行
+func ClearStatus() {
+	SetStatus(nil)
+}
+
+// clearStatus 擦除已绘制的状态行，光标回到第一行行首，调用方需持有锁
+func clearStatus() {
+	if len(statusLines) == 0 {
+		return
+	}
+	var b strings.Builder
+	b.WriteString("\r\033[K")
+	for i := 1; i < len(statusLines); i++ {
+		b.WriteString("\033[1A\033[K")
+	}
+	io.WriteString(statusOut, b.String())
+}
+
+// drawStatus 绘制状态行，光标停在最后一行末尾，调用方需持有锁
+func drawStatus() {
+	if len(statusLines) == 0 {
+		return
+	}
+	io.WriteString(statusOut, strings.Join(statusLines, "\n"))
+}
@@ -0,0 +1,71 @@
+package console
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// defaultWidth 无法获取终端宽度时使用的列数
+const defaultWidth = 80
+
+// terminalWidth 终端的列数：优先读取终端本身，其次环境变量 COLUMNS，都没有时为 80
+func terminalWidth(f *os.File) int {
+	if w := ttyWidth(f); w > 0 {
+		return w
+	}
+	if w, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && w > 0 {
+		return w
+	}
+	return defaultWidth
+}
+
+// truncateWidth 将一行截断到不超过 width 列（中文等宽字符占两列），被截断时以 … 结尾
+func truncateWidth(s string, width int) string {
+	if width <= 0 || displayWidth(s) <= width {
+		return s
+	}
+
+	var b strings.Builder
+	used := 0
+	for _, r := range s {
+		w := runeWidth(r)
+		// 留一列给省略号
+		if used+w > width-1 {
+			break
+		}
+		b.WriteRune(r)
+		used += w
+	}
+	b.WriteString("…")
+	return b.String()
+}
+
+// displayWidth 字符串在终端中占用的列数
+func displayWidth(s string) int {
+	n := 0
+	for _, r := range s {
+		n += runeWidth(r)
+	}
+	return n
+}
+
+// runeWidth 字符占用的列数：控制字符为 0，东亚宽字符和常见 emoji 为 2，其余为 1
+func runeWidth(r rune) int {
+	switch {
+	case r < 0x20 || r == 0x7f:
+		return 0
+	case r >= 0x1100 && r <= 0x115f,
+		r >= 0x2e80 && r <= 0xa4cf && r != 0x303f,
+		r >= 0xac00 && r <= 0xd7a3,
+		r >= 0xf900 && r <= 0xfaff,
+		r >= 0xfe30 && r <= 0xfe4f,
+		r >= 0xff00 && r <= 0xff60,
+		r >= 0xffe0 && r <= 0xffe6,
+		r >= 0x1f300 && r <= 0x1f64f,
+		r >= 0x1f900 && r <= 0x1f9ff,
+		r >= 0x20000 && r <= 0x3fffd:
+		return 2
+	}
+	return 1
+}
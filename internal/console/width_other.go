@@ -0,0 +1,10 @@
+//go:build !linux && !darwin
+
+package console
+
+import "os"
+
+// ttyWidth 该平台不读取终端列数，由调用方使用 COLUMNS 或默认宽度
+func ttyWidth(f *os.File) int {
+	return 0
+}
@@ -0,0 +1,31 @@
+package console
+
+import "testing"
+
+func TestTruncateWidth(t *testing.T) {
+	tests := []struct {
+		name  string
+		s     string
+		width int
+		want  string
+	}{
+		{"不超过宽度", "model.bin 50.0%", 20, "model.bin 50.0%"},
+		{"正好等于宽度", "abcde", 5, "abcde"},
+		{"截断并加省略号", "abcdefgh", 5, "abcd…"},
+		{"中文按两列计算", "模型文件.bin", 8, "模型文…"},
+		{"宽字符不拆开", "模型文件", 6, "模型…"},
+		{"箭头按一列计算", "↓ 1.00 MB ↑ 2.00 MB", 10, "↓ 1.00 MB…"},
+		{"宽度为 0 不截断", "abcdef", 0, "abcdef"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := truncateWidth(tt.s, tt.width)
+			if got != tt.want {
+				t.Errorf("truncateWidth(%q, %d) = %q，应为 %q", tt.s, tt.width, got, tt.want)
+			}
+			if tt.width > 0 && displayWidth(got) > tt.width {
+				t.Errorf("截断后占 %d 列，超过 %d", displayWidth(got), tt.width)
+			}
+		})
+	}
+}
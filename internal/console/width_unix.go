@@ -0,0 +1,21 @@
+//go:build linux || darwin
+
+package console
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// ttyWidth 通过 ioctl 读取终端的列数，失败时返回 0
+func ttyWidth(f *os.File) int {
+	var ws struct {
+		Row, Col, Xpixel, Ypixel uint16
+	}
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), uintptr(syscall.TIOCGWINSZ), uintptr(unsafe.Pointer(&ws)))
+	if errno != 0 {
+		return 0
+	}
+	return int(ws.Col)
+}
@@ -1,5 +1,7 @@
 package constants
 
+import "time"
+
 const (
 	// DownloadedLinksFile 已下载链接记录文件名
 	DownloadedLinksFile = ".link2cos_downloaded.txt"
@@ -16,6 +18,21 @@ const (
 	// MultipartChunkSize 分块上传的块大小：10MB
 	MultipartChunkSize = 10 * 1024 * 1024
 
+	// MaxMultipartParts 单次分块上传允许的最大分块数（COS 限制）
+	MaxMultipartParts = 10000
+
 	// MaxConcurrentUploads 并发上传的最大数量
 	MaxConcurrentUploads = 5
+
+	// RangeChunkSize 分段下载对象时每个 Range 请求的大小：16MB
+	RangeChunkSize = 16 * 1024 * 1024
+
+	// MaxConcurrentRanges 单个对象同时进行的 Range 请求数
+	MaxConcurrentRanges = 4
+
+	// MaxDownloadAttempts 下载大小不符或连接中断时的最大尝试次数
+	MaxDownloadAttempts = 3
+
+	// RetryBackoff 重试前的等待时间，按尝试次数线性增加
+	RetryBackoff = 2 * time.Second
 )
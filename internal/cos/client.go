@@ -4,14 +4,16 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
-	"time"
 
 	"github.com/difyz9/Link2COS/config"
+	"github.com/difyz9/Link2COS/internal/network"
+	"github.com/difyz9/Link2COS/internal/ratelimit"
 	"github.com/tencentyun/cos-go-sdk-v5"
 )
 
 // InitClient 初始化COS客户端（上传到国内COS不需要代理）
-func InitClient(cfg *config.Config) (*cos.Client, error) {
+// limits 为整个运行共享的限速器，nil 表示不限速
+func InitClient(cfg *config.Config, limits *ratelimit.Limits) (*cos.Client, error) {
 	u, err := url.Parse(cfg.COS.BucketURL)
 	if err != nil {
 		return nil, fmt.Errorf("解析Bucket URL失败: %w", err)
@@ -19,14 +21,71 @@ func InitClient(cfg *config.Config) (*cos.Client, error) {
 
 	b := &cos.BaseURL{BucketURL: u}
 
-	// 上传到腾讯云 COS 不使用代理，直连更快
-	client := cos.NewClient(b, &http.Client{
-		Transport: &cos.AuthorizationTransport{
-			SecretID:  cfg.COS.SecretID,
-			SecretKey: cfg.COS.SecretKey,
-		},
-		Timeout: 300 * time.Second,
-	})
+	transport, err := storageTransport(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// 上传停滞时中止该请求：分块由 uploadPart 重试，简单上传返回错误由调用方处理
+	watched, err := network.WatchUpload(transport, cfg.Network.Timeouts)
+	if err != nil {
+		return nil, err
+	}
+
+	signed, err := signingTransport(cfg, limits.WrapUpload(watched))
+	if err != nil {
+		return nil, err
+	}
+	return cos.NewClient(b, &http.Client{Transport: signed}), nil
+}
+
+// NewDownloadClient 创建下载对象用的 HTTP 客户端：与 InitClient 使用相同的代理、TLS 和凭证，
+// 每个请求单独签名，按下载方向限速和检测停滞，可直接交给 download.Downloader 使用
+func NewDownloadClient(cfg *config.Config, limits *ratelimit.Limits) (*http.Client, error) {
+	transport, err := storageTransport(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	watched, err := network.WatchDownload(transport, cfg.Network.Timeouts)
+	if err != nil {
+		return nil, err
+	}
 
-	return client, nil
+	signed, err := signingTransport(cfg, limits.WrapDownload(watched))
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{Transport: signed}, nil
+}
+
+// storageTransport 访问COS的底层连接：代理、分阶段超时和 TLS 设置
+func storageTransport(cfg *config.Config) (*http.Transport, error) {
+	// 上传到腾讯云 COS 默认直连，配置了 network.storage_proxy 或匹配的规则时走代理
+	proxy, err := network.NewStorageProxy(cfg)
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = proxy.Proxy
+	network.ApplyTimeouts(transport, cfg.Network.Timeouts)
+
+	tlsConfig, err := network.NewTLSConfig(cfg.Network.StorageTLS, proxy.Proxies())
+	if err != nil {
+		return nil, fmt.Errorf("COS TLS 配置: %w", err)
+	}
+	if tlsConfig != nil {
+		transport.TLSClientConfig = tlsConfig
+	}
+	return transport, nil
+}
+
+// signingTransport 用配置的凭证为请求签名
+func signingTransport(cfg *config.Config, next http.RoundTripper) (http.RoundTripper, error) {
+	// 凭证提供者：静态密钥，或自动刷新的临时凭证
+	provider, err := NewCredentialProvider(&cfg.COS)
+	if err != nil {
+		return nil, err
+	}
+	return newRefreshingTransport(provider, cfg.COS.CredentialProvider.RefreshBefore, next), nil
 }
@@ -0,0 +1,248 @@
+package cos
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/difyz9/Link2COS/config"
+	"github.com/difyz9/Link2COS/internal/console"
+	"github.com/tencentyun/cos-go-sdk-v5"
+)
+
+// defaultRefreshBefore 临时凭证过期前多久刷新
+const defaultRefreshBefore = 5 * time.Minute
+
+// Credential 访问凭证，Expiration 为零值表示长期有效
+type Credential struct {
+	SecretID     string
+	SecretKey    string
+	SessionToken string
+	Expiration   time.Time
+}
+
+// CredentialProvider 凭证提供者，临时凭证过期前会被再次调用
+type CredentialProvider interface {
+	Retrieve(ctx context.Context) (*Credential, error)
+}
+
+// NewCredentialProvider 根据配置创建凭证提供者：
+// 未配置 credential_provider 时使用静态密钥（可带 session_token），否则从 HTTP 端点或外部命令获取临时凭证
+func NewCredentialProvider(cfg *config.COSConfig) (CredentialProvider, error) {
+	p := cfg.CredentialProvider
+	switch strings.ToLower(p.Type) {
+	case "", "static":
+		return &staticProvider{cred: Credential{
+			SecretID:     cfg.SecretID,
+			SecretKey:    cfg.SecretKey,
+			SessionToken: cfg.SessionToken,
+		}}, nil
+	case "http":
+		if p.URL == "" {
+			return nil, fmt.Errorf("credential_provider.url 不能为空")
+		}
+		return &httpProvider{url: p.URL, headers: p.Headers, client: &http.Client{Timeout: 30 * time.Second}}, nil
+	case "command":
+		if len(p.Command) == 0 {
+			return nil, fmt.Errorf("credential_provider.command 不能为空")
+		}
+		return &commandProvider{command: p.Command}, nil
+	}
+	return nil, fmt.Errorf("未知的凭证提供者类型: %s（可选: static, http, command）", p.Type)
+}
+
+// staticProvider 固定的密钥
+type staticProvider struct {
+	cred Credential
+}
+
+func (p *staticProvider) Retrieve(ctx context.Context) (*Credential, error) {
+	cred := p.cred
+	return &cred, nil
+}
+
+// httpProvider 从 STS 风格的 HTTP 端点获取临时凭证
+type httpProvider struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+}
+
+func (p *httpProvider) Retrieve(ctx context.Context) (*Credential, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range p.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求凭证端点失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("读取凭证端点响应失败: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("凭证端点返回 HTTP状态码: %d", resp.StatusCode)
+	}
+
+	return parseCredential(body)
+}
+
+// commandProvider 运行外部命令，从标准输出读取 JSON 格式的临时凭证
+type commandProvider struct {
+	command []string
+}
+
+func (p *commandProvider) Retrieve(ctx context.Context) (*Credential, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, p.command[0], p.command[1:]...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("运行凭证命令失败: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	return parseCredential(stdout.Bytes())
+}
+
+// stsCredentials 兼容 STS GetFederationToken / AssumeRole 响应和 CVM 元数据服务的字段
+type stsCredentials struct {
+	TmpSecretID  string `json:"TmpSecretId"`
+	TmpSecretKey string `json:"TmpSecretKey"`
+	Token        string `json:"Token"`
+	SessionToken string `json:"SessionToken"`
+	ExpiredTime  int64  `json:"ExpiredTime"`
+	Expiration   string `json:"Expiration"`
+}
+
+type stsResponse struct {
+	stsCredentials
+	Credentials *stsCredentials `json:"Credentials"`
+	Response    *stsResponse    `json:"Response"`
+}
+
+// parseCredential 解析临时凭证 JSON，支持顶层字段、Credentials 嵌套和云 API 的 Response 包装
+func parseCredential(data []byte) (*Credential, error) {
+	var resp stsResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("解析临时凭证失败: %w", err)
+	}
+	if resp.Response != nil {
+		resp = *resp.Response
+	}
+
+	c := resp.stsCredentials
+	if resp.Credentials != nil {
+		nested := *resp.Credentials
+		// 过期时间通常与 Credentials 同级
+		if nested.ExpiredTime == 0 {
+			nested.ExpiredTime = c.ExpiredTime
+		}
+		if nested.Expiration == "" {
+			nested.Expiration = c.Expiration
+		}
+		c = nested
+	}
+
+	cred := &Credential{
+		SecretID:     c.TmpSecretID,
+		SecretKey:    c.TmpSecretKey,
+		SessionToken: c.Token,
+	}
+	if cred.SessionToken == "" {
+		cred.SessionToken = c.SessionToken
+	}
+	if cred.SecretID == "" || cred.SecretKey == "" {
+		return nil, fmt.Errorf("临时凭证缺少 TmpSecretId 或 TmpSecretKey")
+	}
+
+	switch {
+	case c.ExpiredTime > 0:
+		cred.Expiration = time.Unix(c.ExpiredTime, 0)
+	case c.Expiration != "":
+		exp, err := time.Parse(time.RFC3339, c.Expiration)
+		if err != nil {
+			return nil, fmt.Errorf("解析凭证过期时间失败: %w", err)
+		}
+		cred.Expiration = exp
+	}
+
+	return cred, nil
+}
+
+// refreshingTransport 每次请求前检查凭证是否即将过期，必要时刷新后再交给 AuthorizationTransport 签名
+type refreshingTransport struct {
+	auth          *cos.AuthorizationTransport
+	provider      CredentialProvider
+	refreshBefore time.Duration
+
+	mu         sync.Mutex
+	expiration time.Time
+	loaded     bool
+	retryAfter time.Time // 刷新失败后暂缓重试，避免每个请求都调用凭证源
+}
+
+func newRefreshingTransport(provider CredentialProvider, refreshBefore time.Duration, base http.RoundTripper) *refreshingTransport {
+	if refreshBefore <= 0 {
+		refreshBefore = defaultRefreshBefore
+	}
+	return &refreshingTransport{
+		auth:          &cos.AuthorizationTransport{Transport: base},
+		provider:      provider,
+		refreshBefore: refreshBefore,
+	}
+}
+
+// RoundTrip implements the RoundTripper interface.
+func (t *refreshingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.ensureFresh(req.Context()); err != nil {
+		return nil, err
+	}
+	return t.auth.RoundTrip(req)
+}
+
+// ensureFresh 首次使用或临近过期时刷新凭证；刷新失败但旧凭证仍有效时继续使用旧凭证
+func (t *refreshingTransport) ensureFresh(ctx context.Context) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	if t.loaded && (t.expiration.IsZero() || now.Before(t.expiration.Add(-t.refreshBefore))) {
+		return nil
+	}
+	if t.loaded && now.Before(t.retryAfter) && now.Before(t.expiration) {
+		return nil
+	}
+
+	cred, err := t.provider.Retrieve(ctx)
+	if err != nil {
+		if t.loaded && now.Before(t.expiration) {
+			t.retryAfter = now.Add(30 * time.Second)
+			console.Printf("  警告: 刷新临时凭证失败，继续使用当前凭证（%s 过期）: %v\n", t.expiration.Format(time.RFC3339), err)
+			return nil
+		}
+		return fmt.Errorf("获取COS凭证失败: %w", err)
+	}
+
+	t.auth.SetCredential(cred.SecretID, cred.SecretKey, cred.SessionToken)
+	t.expiration = cred.Expiration
+	if t.loaded && !cred.Expiration.IsZero() {
+		console.Printf("  已刷新临时凭证，有效期至 %s\n", cred.Expiration.Format(time.RFC3339))
+	}
+	t.loaded = true
+	return nil
+}
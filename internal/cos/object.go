@@ -0,0 +1,195 @@
+package cos
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/difyz9/Link2COS/internal/constants"
+	"github.com/tencentyun/cos-go-sdk-v5"
+)
+
+// MetaMtime 记录本地文件修改时间（Unix 秒）的元数据，mirror 据此判断文件是否变化
+const MetaMtime = "x-cos-meta-mtime"
+
+// listPageSize 每页列举的最大条目数（COS 限制）
+const listPageSize = 1000
+
+// deleteBatchSize 批量删除每次请求的对象数（COS 限制）
+const deleteBatchSize = 1000
+
+// ObjectInfo 对象的大小和校验值
+type ObjectInfo struct {
+	Size  int64
+	CRC64 string    // x-cos-hash-crc64ecma，十进制；没有该元数据的对象为空
+	Mtime time.Time // 上传时记录的本地修改时间，没有记录时为零值
+	ETag  string    // 对象内容变化时改变，用于判断续传的是否为同一版本
+}
+
+// ListedObject 列举得到的对象
+type ListedObject struct {
+	Key          string
+	Size         int64
+	LastModified time.Time
+	ETag         string
+	StorageClass string
+}
+
+// ListOptions 分页列举的条件
+type ListOptions struct {
+	Prefix    string
+	Delimiter string // 为 / 时只列出下一级，更深的对象合并为目录
+	Marker    string // 从该键之后开始列举
+	MaxKeys   int    // 本页最多返回的条目数（对象和目录合计），0 表示 1000
+}
+
+// ListPage 一页列举结果
+type ListPage struct {
+	Objects    []ListedObject
+	Prefixes   []string // 使用分隔符时的下一级目录，以分隔符结尾
+	NextMarker string   // 下一页的起点，为空表示已列完
+}
+
+// FileMetadata 上传本地文件时附加的元数据：记录文件的修改时间
+func FileMetadata(info os.FileInfo) http.Header {
+	meta := http.Header{}
+	meta.Set(MetaMtime, strconv.FormatInt(info.ModTime().Unix(), 10))
+	return meta
+}
+
+// ParseURL 解析 cos://前缀 形式的地址，返回对象键的前缀（不以 / 开头），存储桶由配置决定
+func ParseURL(s string) (string, error) {
+	if !strings.HasPrefix(s, "cos://") {
+		return "", fmt.Errorf("COS地址应以 cos:// 开头，例如 cos://models/: %s", s)
+	}
+	return strings.TrimLeft(strings.TrimPrefix(s, "cos://"), "/"), nil
+}
+
+// StatObject 查询对象的信息，对象不存在时返回 nil
+func StatObject(ctx context.Context, client *cos.Client, key string) (*ObjectInfo, error) {
+	resp, err := client.Object.Head(ctx, key, nil)
+	if err != nil {
+		if cos.IsNotFoundError(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	info := &ObjectInfo{
+		Size:  resp.ContentLength,
+		CRC64: resp.Header.Get("x-cos-hash-crc64ecma"),
+		ETag:  resp.Header.Get("ETag"),
+	}
+	if sec, err := strconv.ParseInt(resp.Header.Get(MetaMtime), 10, 64); err == nil {
+		info.Mtime = time.Unix(sec, 0)
+	}
+	return info, nil
+}
+
+// ListObjectsPage 列举一页对象，包括以 / 结尾的目录占位对象
+func ListObjectsPage(ctx context.Context, client *cos.Client, opts ListOptions) (*ListPage, error) {
+	maxKeys := opts.MaxKeys
+	if maxKeys <= 0 || maxKeys > listPageSize {
+		maxKeys = listPageSize
+	}
+	result, _, err := client.Bucket.Get(ctx, &cos.BucketGetOptions{
+		Prefix:    opts.Prefix,
+		Delimiter: opts.Delimiter,
+		Marker:    opts.Marker,
+		MaxKeys:   maxKeys,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("列出对象失败: %w", err)
+	}
+
+	page := &ListPage{Prefixes: result.CommonPrefixes}
+	for _, obj := range result.Contents {
+		modified, _ := time.Parse(time.RFC3339, obj.LastModified)
+		page.Objects = append(page.Objects, ListedObject{
+			Key:          obj.Key,
+			Size:         obj.Size,
+			LastModified: modified,
+			ETag:         obj.ETag,
+			StorageClass: obj.StorageClass,
+		})
+	}
+	if result.IsTruncated {
+		// 未使用分隔符时 COS 可能不返回 NextMarker，从本页最后一个条目继续
+		page.NextMarker = result.NextMarker
+		if page.NextMarker == "" && len(result.Contents) > 0 {
+			page.NextMarker = result.Contents[len(result.Contents)-1].Key
+		}
+		if n := len(result.CommonPrefixes); n > 0 && result.CommonPrefixes[n-1] > page.NextMarker {
+			page.NextMarker = result.CommonPrefixes[n-1]
+		}
+	}
+	return page, nil
+}
+
+// ListObjects 列出前缀下的所有对象（自动翻页），不包括以 / 结尾的目录占位对象
+func ListObjects(ctx context.Context, client *cos.Client, prefix string) ([]ListedObject, error) {
+	var objects []ListedObject
+	opts := ListOptions{Prefix: prefix}
+	for {
+		page, err := ListObjectsPage(ctx, client, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, obj := range page.Objects {
+			if !strings.HasSuffix(obj.Key, "/") {
+				objects = append(objects, obj)
+			}
+		}
+		if page.NextMarker == "" {
+			return objects, nil
+		}
+		opts.Marker = page.NextMarker
+	}
+}
+
+// DeleteObjects 批量删除对象，返回删除失败的对象及原因
+// 请求本身失败时停止，剩余的对象都计为失败，并返回该错误
+func DeleteObjects(ctx context.Context, client *cos.Client, keys []string) (map[string]string, error) {
+	failed := make(map[string]string)
+	for start := 0; start < len(keys); start += deleteBatchSize {
+		end := min(start+deleteBatchSize, len(keys))
+		opt := &cos.ObjectDeleteMultiOptions{Quiet: true}
+		for _, key := range keys[start:end] {
+			opt.Objects = append(opt.Objects, cos.Object{Key: key})
+		}
+		result, _, err := client.Object.DeleteMulti(ctx, opt)
+		if err != nil {
+			for _, key := range keys[start:] {
+				failed[key] = err.Error()
+			}
+			return failed, fmt.Errorf("删除对象失败: %w", err)
+		}
+		for _, e := range result.Errors {
+			failed[e.Key] = fmt.Sprintf("%s: %s", e.Code, e.Message)
+		}
+	}
+	return failed, nil
+}
+
+// HeadObject 查询对象的大小，对象不存在时 exists 为 false
+func HeadObject(ctx context.Context, client *cos.Client, key string) (size int64, exists bool, err error) {
+	info, err := StatObject(ctx, client, key)
+	if err != nil || info == nil {
+		return 0, false, err
+	}
+	return info.Size, true, nil
+}
+
+// PartCount 预计的上传分块数：0 表示一次上传（小文件），-1 表示大小未知、边读边分块
+func PartCount(size int64) int {
+	switch {
+	case size < 0:
+		return -1
+	case size < constants.SmallFileSizeThreshold:
+		return 0
+	}
+	return int((size + constants.MultipartChunkSize - 1) / constants.MultipartChunkSize)
+}
@@ -0,0 +1,44 @@
+package cos
+
+import (
+	"github.com/difyz9/Link2COS/internal/progress"
+	"github.com/tencentyun/cos-go-sdk-v5"
+)
+
+// listener 返回统计上传字节数的 SDK 回调，未设置进度时返回 nil
+func (u *Uploader) listener() cos.ProgressListener {
+	if u.progress == nil {
+		return nil
+	}
+	return &progressListener{file: u.progress}
+}
+
+// progressListener 将 SDK 的上传进度计入文件进度
+// 每次请求使用一个实例；请求失败或重新发送数据时，先撤销上一次已计入的字节
+type progressListener struct {
+	file     *progress.File
+	consumed int64
+}
+
+func (l *progressListener) ProgressChangedCallback(event *cos.ProgressEvent) {
+	switch event.EventType {
+	case cos.ProgressStartedEvent, cos.ProgressFailedEvent:
+		l.undo()
+	case cos.ProgressDataEvent:
+		l.file.AddUploaded(event.RWBytes)
+		l.consumed += event.RWBytes
+	}
+}
+
+// undo 撤销本次请求已计入的字节数
+func (l *progressListener) undo() {
+	l.file.AddUploaded(-l.consumed)
+	l.consumed = 0
+}
+
+// undoProgress 请求失败时撤销 listener 已计入的字节数，listener 可以为 nil
+func undoProgress(listener cos.ProgressListener) {
+	if l, ok := listener.(*progressListener); ok {
+		l.undo()
+	}
+}
@@ -0,0 +1,142 @@
+package cos
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/difyz9/Link2COS/internal/console"
+	"github.com/difyz9/Link2COS/internal/constants"
+	"github.com/tencentyun/cos-go-sdk-v5"
+)
+
+// uploadStream 大小未知的数据流（例如 chunked 响应）：边读边并发分块上传
+// 数据不足一个分块时直接上传，不创建分块上传任务
+func (u *Uploader) uploadStream(ctx context.Context, reader io.Reader, cosPath string) error {
+	first := make([]byte, constants.MultipartChunkSize)
+	n, eof, err := readChunk(reader, first)
+	if err != nil {
+		return fmt.Errorf("读取数据失败: %w", err)
+	}
+	if eof {
+		console.Printf("  策略: 内存上传 (%.2f MB)\n", float64(n)/(1024*1024))
+		return u.uploadBytes(ctx, first[:n], cosPath)
+	}
+
+	console.Println("  策略: 流式分块上传（大小未知）")
+
+	initRes, _, err := u.client.Object.InitiateMultipartUpload(ctx, cosPath, u.initOptions())
+	if err != nil {
+		return fmt.Errorf("初始化分块上传失败: %w", err)
+	}
+	uploadID := initRes.UploadID
+
+	var (
+		mu        sync.Mutex
+		parts     []cos.Object
+		uploadErr error
+		wg        sync.WaitGroup
+	)
+	semaphore := make(chan struct{}, constants.MaxConcurrentUploads)
+
+	failed := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return uploadErr != nil
+	}
+
+	// 读取一块就交给一个协程上传，内存中最多保留 MaxConcurrentUploads+1 个分块
+	data := first[:n]
+	for partNumber := 1; ; partNumber++ {
+		if partNumber > constants.MaxMultipartParts {
+			err = fmt.Errorf("分块数超过上限 %d", constants.MaxMultipartParts)
+			break
+		}
+
+		semaphore <- struct{}{}
+		if failed() {
+			<-semaphore
+			break
+		}
+		if err = ctx.Err(); err != nil {
+			<-semaphore
+			break
+		}
+
+		wg.Add(1)
+		go func(pn int, data []byte) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			etag, err := u.uploadPart(ctx, cosPath, uploadID, pn, data)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if uploadErr == nil {
+					uploadErr = err
+				}
+				return
+			}
+			parts = append(parts, cos.Object{PartNumber: pn, ETag: etag})
+		}(partNumber, data)
+
+		if eof {
+			break
+		}
+
+		buf := make([]byte, constants.MultipartChunkSize)
+		n, eof, err = readChunk(reader, buf)
+		if err != nil {
+			err = fmt.Errorf("读取数据失败: %w", err)
+			break
+		}
+		if n == 0 {
+			break
+		}
+		data = buf[:n]
+	}
+
+	wg.Wait()
+
+	if err == nil {
+		err = uploadErr
+		if err != nil {
+			err = fmt.Errorf("上传分块失败: %w", err)
+		}
+	}
+	if err != nil {
+		u.abort(cosPath, uploadID)
+		return err
+	}
+
+	// COS需要按顺序提交parts
+	sort.Slice(parts, func(i, j int) bool { return parts[i].PartNumber < parts[j].PartNumber })
+
+	completeOpt := &cos.CompleteMultipartUploadOptions{Parts: parts}
+	if _, _, err := u.client.Object.CompleteMultipartUpload(ctx, cosPath, uploadID, completeOpt); err != nil {
+		u.abort(cosPath, uploadID)
+		return fmt.Errorf("完成分块上传失败: %w", err)
+	}
+
+	return nil
+}
+
+// readChunk 尽量读满 buf，返回读取的字节数以及是否已读到结尾
+// 与 io.ReadFull 不同，正常结束不会被当作 io.ErrUnexpectedEOF，便于区分连接中断
+func readChunk(reader io.Reader, buf []byte) (int, bool, error) {
+	n := 0
+	for n < len(buf) {
+		m, err := reader.Read(buf[n:])
+		n += m
+		if err == io.EOF {
+			return n, true, nil
+		}
+		if err != nil {
+			return n, false, err
+		}
+	}
+	return n, false, nil
+}
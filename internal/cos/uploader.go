@@ -3,18 +3,31 @@ package cos
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
 	"sync"
+	"time"
 
+	"github.com/difyz9/Link2COS/internal/console"
 	"github.com/difyz9/Link2COS/internal/constants"
+	"github.com/difyz9/Link2COS/internal/events"
+	"github.com/difyz9/Link2COS/internal/network"
+	"github.com/difyz9/Link2COS/internal/progress"
+	"github.com/difyz9/Link2COS/internal/util"
 	"github.com/tencentyun/cos-go-sdk-v5"
 )
 
+// abortTimeout 中止分块上传的超时时间
+const abortTimeout = 30 * time.Second
+
 // Uploader 文件上传器
 type Uploader struct {
-	client *cos.Client
+	client   *cos.Client
+	progress *progress.File
+	metadata *http.Header // 上传时附加的 x-cos-meta-* 元数据
 }
 
 // NewUploader 创建上传器
@@ -22,8 +35,18 @@ func NewUploader(client *cos.Client) *Uploader {
 	return &Uploader{client: client}
 }
 
+// SetProgress 设置文件的进度，上传的字节数计入其中
+func (u *Uploader) SetProgress(file *progress.File) {
+	u.progress = file
+}
+
+// SetMetadata 设置对象的自定义元数据（x-cos-meta-*），例如 FileMetadata 记录的修改时间
+func (u *Uploader) SetMetadata(meta http.Header) {
+	u.metadata = &meta
+}
+
 // UploadFile 上传本地文件到COS（自动选择策略）
-func (u *Uploader) UploadFile(localFile, cosPath string) error {
+func (u *Uploader) UploadFile(ctx context.Context, localFile, cosPath string) error {
 	// 获取文件信息
 	fileInfo, err := os.Stat(localFile)
 	if err != nil {
@@ -34,63 +57,82 @@ func (u *Uploader) UploadFile(localFile, cosPath string) error {
 
 	// 根据文件大小选择上传策略
 	if fileSize < constants.SmallFileSizeThreshold {
-		fmt.Printf("  策略: 内存上传 (%.2f MB)\n", float64(fileSize)/(1024*1024))
-		return u.uploadFromMemory(localFile, cosPath)
+		console.Printf("  策略: 内存上传 (%.2f MB)\n", float64(fileSize)/(1024*1024))
+		return u.uploadFromMemory(ctx, localFile, cosPath)
 	} else {
-		fmt.Printf("  策略: 分块上传 (%.2f MB)\n", float64(fileSize)/(1024*1024))
-		return u.uploadMultipart(localFile, cosPath, fileSize)
+		console.Printf("  策略: 分块上传 (%.2f MB)\n", float64(fileSize)/(1024*1024))
+		return u.uploadMultipart(ctx, localFile, cosPath, fileSize)
 	}
 }
 
-// UploadFromReader 从Reader上传到COS（用于下载的文件）
-func (u *Uploader) UploadFromReader(reader io.Reader, cosPath string, size int64) error {
+// UploadFromReader 从Reader上传到COS（用于下载的文件），size < 0 表示大小未知
+func (u *Uploader) UploadFromReader(ctx context.Context, reader io.Reader, cosPath string, size int64) error {
+	if size < 0 {
+		// 大小未知：边读边分块上传
+		return u.uploadStream(ctx, reader, cosPath)
+	}
+
 	if size < constants.SmallFileSizeThreshold {
 		// 小文件：读取到内存后上传
 		data, err := io.ReadAll(reader)
 		if err != nil {
 			return fmt.Errorf("读取数据失败: %w", err)
 		}
+		if int64(len(data)) != size {
+			return fmt.Errorf("读取数据大小不符: 期望 %d 字节，实际 %d 字节", size, len(data))
+		}
 
-		return u.uploadBytes(data, cosPath)
+		return u.uploadBytes(ctx, data, cosPath)
 	} else {
 		// 大文件：先保存到临时文件，再分块上传
-		tmpFile, err := u.saveTempFile(reader)
+		tmpFile, written, err := u.saveTempFile(reader)
 		if err != nil {
 			return fmt.Errorf("保存临时文件失败: %w", err)
 		}
 		defer os.Remove(tmpFile)
 
-		return u.uploadMultipart(tmpFile, cosPath, size)
+		if written != size {
+			return fmt.Errorf("读取数据大小不符: 期望 %d 字节，实际 %d 字节", size, written)
+		}
+
+		return u.uploadMultipart(ctx, tmpFile, cosPath, size)
 	}
 }
 
 // uploadFromMemory 小文件：读取到内存后上传
-func (u *Uploader) uploadFromMemory(localFile, cosPath string) error {
+func (u *Uploader) uploadFromMemory(ctx context.Context, localFile, cosPath string) error {
 	data, err := os.ReadFile(localFile)
 	if err != nil {
 		return fmt.Errorf("读取文件失败: %w", err)
 	}
 
-	return u.uploadBytes(data, cosPath)
+	return u.uploadBytes(ctx, data, cosPath)
 }
 
-// uploadBytes 从字节数组上传
-func (u *Uploader) uploadBytes(data []byte, cosPath string) error {
-	reader := bytes.NewReader(data)
-	opt := &cos.ObjectPutOptions{
-		ObjectPutHeaderOptions: &cos.ObjectPutHeaderOptions{
-			ContentLength: int64(len(data)),
-		},
-	}
+// uploadBytes 从字节数组上传，传输停滞时重试
+func (u *Uploader) uploadBytes(ctx context.Context, data []byte, cosPath string) error {
+	return retryStalled(ctx, "上传", func() error {
+		listener := u.listener()
+		opt := &cos.ObjectPutOptions{
+			ObjectPutHeaderOptions: &cos.ObjectPutHeaderOptions{
+				ContentLength: int64(len(data)),
+				XCosMetaXXX:   u.metadata,
+				Listener:      listener,
+			},
+		}
 
-	_, err := u.client.Object.Put(context.Background(), cosPath, reader, opt)
-	return err
+		_, err := u.client.Object.Put(ctx, cosPath, bytes.NewReader(data), opt)
+		if err != nil {
+			undoProgress(listener)
+		}
+		return err
+	})
 }
 
 // uploadMultipart 大文件：使用并发分块上传
-func (u *Uploader) uploadMultipart(localFile, cosPath string, fileSize int64) error {
+func (u *Uploader) uploadMultipart(ctx context.Context, localFile, cosPath string, fileSize int64) error {
 	// 初始化分块上传
-	initRes, _, err := u.client.Object.InitiateMultipartUpload(context.Background(), cosPath, nil)
+	initRes, _, err := u.client.Object.InitiateMultipartUpload(ctx, cosPath, u.initOptions())
 	if err != nil {
 		return fmt.Errorf("初始化分块上传失败: %w", err)
 	}
@@ -99,7 +141,7 @@ func (u *Uploader) uploadMultipart(localFile, cosPath string, fileSize int64) er
 
 	// 计算分块数量
 	totalParts := int((fileSize + constants.MultipartChunkSize - 1) / constants.MultipartChunkSize)
-	fmt.Printf("  总分块数: %d\n", totalParts)
+	console.Printf("  总分块数: %d\n", totalParts)
 
 	// 并发上传分块
 	type partResult struct {
@@ -122,6 +164,12 @@ func (u *Uploader) uploadMultipart(localFile, cosPath string, fileSize int64) er
 			semaphore <- struct{}{}
 			defer func() { <-semaphore }()
 
+			// 已取消时不再开始新的分块
+			if err := ctx.Err(); err != nil {
+				resultChan <- partResult{partNumber: pn, err: err}
+				return
+			}
+
 			// 读取分块数据
 			offset := int64(pn-1) * constants.MultipartChunkSize
 			size := constants.MultipartChunkSize
@@ -143,13 +191,9 @@ func (u *Uploader) uploadMultipart(localFile, cosPath string, fileSize int64) er
 				return
 			}
 
-			// 上传分块
-			etag, err := u.uploadPart(cosPath, uploadID, pn, data)
+			// 上传分块，进度按字节统计
+			etag, err := u.uploadPart(ctx, cosPath, uploadID, pn, data)
 			resultChan <- partResult{partNumber: pn, etag: etag, err: err}
-
-			if err == nil {
-				fmt.Printf("  已上传: %d/%d 块 (%.1f%%)\n", pn, totalParts, float64(pn)*100/float64(totalParts))
-			}
 		}(partNumber)
 	}
 
@@ -175,7 +219,7 @@ func (u *Uploader) uploadMultipart(localFile, cosPath string, fileSize int64) er
 
 	// 如果有错误，终止上传
 	if uploadErr != nil {
-		u.client.Object.AbortMultipartUpload(context.Background(), cosPath, uploadID)
+		u.abort(cosPath, uploadID)
 		return fmt.Errorf("上传分块失败: %w", uploadErr)
 	}
 
@@ -191,46 +235,108 @@ func (u *Uploader) uploadMultipart(localFile, cosPath string, fileSize int64) er
 		Parts: sortedParts,
 	}
 
-	_, _, err = u.client.Object.CompleteMultipartUpload(context.Background(), cosPath, uploadID, completeOpt)
+	_, _, err = u.client.Object.CompleteMultipartUpload(ctx, cosPath, uploadID, completeOpt)
 	if err != nil {
-		u.client.Object.AbortMultipartUpload(context.Background(), cosPath, uploadID)
+		u.abort(cosPath, uploadID)
 		return fmt.Errorf("完成分块上传失败: %w", err)
 	}
 
 	return nil
 }
 
-// uploadPart 上传单个分块
-func (u *Uploader) uploadPart(cosPath, uploadID string, partNumber int, data []byte) (string, error) {
-	reader := bytes.NewReader(data)
+// initOptions 初始化分块上传的选项，附加自定义元数据
+func (u *Uploader) initOptions() *cos.InitiateMultipartUploadOptions {
+	if u.metadata == nil {
+		return nil
+	}
+	return &cos.InitiateMultipartUploadOptions{
+		ObjectPutHeaderOptions: &cos.ObjectPutHeaderOptions{XCosMetaXXX: u.metadata},
+	}
+}
+
+// uploadPart 上传单个分块，传输停滞时重试该分块
+func (u *Uploader) uploadPart(ctx context.Context, cosPath, uploadID string, partNumber int, data []byte) (string, error) {
+	var etag string
+	err := retryStalled(ctx, fmt.Sprintf("分块 %d ", partNumber), func() error {
+		var err error
+		etag, err = u.uploadPartOnce(ctx, cosPath, uploadID, partNumber, data)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	events.Emit("part_uploaded", events.Fields{
+		"key":       cosPath,
+		"upload_id": uploadID,
+		"part":      partNumber,
+		"size":      len(data),
+		"etag":      etag,
+	})
+	return etag, nil
+}
+
+// retryStalled 执行 fn，传输停滞时按下载分段相同的次数和间隔重试
+// SDK 对 io.Reader 形式的请求体只发送一次，停滞的请求需要在这里重试，否则整个文件会失败
+func retryStalled(ctx context.Context, what string, fn func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		var stall *network.StallError
+		if err == nil || !errors.As(err, &stall) || attempt >= constants.MaxDownloadAttempts {
+			return err
+		}
+
+		console.Printf("  %s重试 (%d/%d): %v\n", what, attempt, constants.MaxDownloadAttempts-1, err)
+		if err := util.Sleep(ctx, time.Duration(attempt)*constants.RetryBackoff); err != nil {
+			return err
+		}
+	}
+}
+
+// uploadPartOnce 上传一次分块（不重试），失败时撤销已计入进度的字节数
+func (u *Uploader) uploadPartOnce(ctx context.Context, cosPath, uploadID string, partNumber int, data []byte) (string, error) {
+	listener := u.listener()
 	resp, err := u.client.Object.UploadPart(
-		context.Background(),
+		ctx,
 		cosPath,
 		uploadID,
 		partNumber,
-		reader,
-		nil,
+		bytes.NewReader(data),
+		&cos.ObjectUploadPartOptions{Listener: listener},
 	)
 	if err != nil {
+		undoProgress(listener)
 		return "", err
 	}
-
 	return resp.Header.Get("ETag"), nil
 }
 
-// saveTempFile 保存到临时文件
-func (u *Uploader) saveTempFile(reader io.Reader) (string, error) {
+// abort 中止分块上传并清理已上传的分块
+// 使用独立的 context：传输被取消后仍需要完成清理，避免在存储桶中留下碎片
+func (u *Uploader) abort(cosPath, uploadID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), abortTimeout)
+	defer cancel()
+
+	if _, err := u.client.Object.AbortMultipartUpload(ctx, cosPath, uploadID); err != nil {
+		console.Eprintf("  警告: 中止分块上传失败（%s，UploadId: %s）: %v\n", cosPath, uploadID, err)
+		return
+	}
+	console.Printf("  已中止分块上传: %s\n", cosPath)
+}
+
+// saveTempFile 保存到临时文件，返回临时文件路径和写入的字节数
+func (u *Uploader) saveTempFile(reader io.Reader) (string, int64, error) {
 	tmpFile, err := os.CreateTemp("", "link2cos-*")
 	if err != nil {
-		return "", err
+		return "", 0, err
 	}
 	defer tmpFile.Close()
 
-	_, err = io.Copy(tmpFile, reader)
+	written, err := io.Copy(tmpFile, reader)
 	if err != nil {
 		os.Remove(tmpFile.Name())
-		return "", err
+		return "", written, err
 	}
 
-	return tmpFile.Name(), nil
+	return tmpFile.Name(), written, nil
 }
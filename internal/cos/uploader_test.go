@@ -0,0 +1,58 @@
+package cos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"testing"
+
+	"github.com/difyz9/Link2COS/internal/network"
+)
+
+func TestRetryStalled(t *testing.T) {
+	stall := &url.Error{Op: "Put", URL: "https://bucket/key", Err: &network.StallError{Reason: "测试"}}
+	other := errors.New("AccessDenied")
+
+	tests := []struct {
+		name      string
+		errs      []error // 依次返回的错误，用完后返回 nil
+		wantCalls int
+		wantErr   error
+	}{
+		{"成功不重试", nil, 1, nil},
+		{"停滞后重试成功", []error{stall}, 2, nil},
+		{"其他错误不重试", []error{other}, 1, other},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			err := retryStalled(context.Background(), "分块 1 ", func() error {
+				calls++
+				if calls <= len(tt.errs) {
+					return tt.errs[calls-1]
+				}
+				return nil
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("返回 %v，应为 %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("调用 %d 次，应为 %d 次", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestRetryStalledStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	err := retryStalled(ctx, "上传", func() error {
+		calls++
+		cancel()
+		return fmt.Errorf("上传: %w", &network.StallError{Reason: "测试"})
+	})
+	if !errors.Is(err, context.Canceled) || calls != 1 {
+		t.Errorf("取消后应停止重试，得到 %v（调用 %d 次）", err, calls)
+	}
+}
@@ -0,0 +1,55 @@
+package download
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// partSuffix 下载中的临时文件后缀
+const partSuffix = ".part"
+
+// writeFileAtomic 先把数据写入同目录下的 .part 文件，落盘并校验大小后再重命名为目标文件，
+// 中断或失败时不会留下看起来完整的截断文件。expectedSize < 0 表示大小未知，不做校验
+func writeFileAtomic(localPath string, reader io.Reader, expectedSize int64) (int64, error) {
+	partPath := localPath + partSuffix
+
+	file, err := os.Create(partPath)
+	if err != nil {
+		return 0, fmt.Errorf("创建临时文件失败: %w", err)
+	}
+
+	written, err := io.Copy(file, reader)
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(partPath)
+		return written, fmt.Errorf("保存文件失败: %w", err)
+	}
+
+	if expectedSize >= 0 && written != expectedSize {
+		os.Remove(partPath)
+		return written, &SizeMismatchError{Expected: expectedSize, Actual: written}
+	}
+
+	if err := os.Rename(partPath, localPath); err != nil {
+		os.Remove(partPath)
+		return written, fmt.Errorf("重命名临时文件失败: %w", err)
+	}
+
+	syncDir(filepath.Dir(localPath))
+	return written, nil
+}
+
+// syncDir 同步目录项，确保重命名本身也已落盘（部分平台不支持，忽略错误）
+func syncDir(path string) {
+	if dir, err := os.Open(path); err == nil {
+		dir.Sync()
+		dir.Close()
+	}
+}
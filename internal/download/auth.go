@@ -0,0 +1,100 @@
+package download
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/difyz9/Link2COS/config"
+	"github.com/difyz9/Link2COS/internal/network"
+)
+
+// authTransport 按请求的主机附加认证和请求头
+// 每个请求（包括重定向后的请求）单独匹配主机，重定向到其他主机时不会携带原主机的凭证；
+// .netrc 的 default 只用于原始链接的主机，重定向到 CDN 等其他主机时不会带上
+type authTransport struct {
+	base      http.RoundTripper
+	userAgent string
+	hosts     []config.SourceHost
+	netrc     []netrcEntry
+}
+
+// newAuthTransport 根据 sources 配置包装 base，未配置任何认证和请求头时直接返回 base
+func newAuthTransport(base http.RoundTripper, sources config.SourcesConfig) (http.RoundTripper, error) {
+	t := &authTransport{
+		base:      base,
+		userAgent: sources.UserAgent,
+		hosts:     sources.Hosts,
+	}
+
+	if sources.Netrc {
+		entries, err := readNetrc(netrcPath(sources.NetrcFile))
+		if err != nil {
+			return nil, err
+		}
+		t.netrc = entries
+	}
+
+	if t.userAgent == "" && len(t.hosts) == 0 && len(t.netrc) == 0 {
+		return base, nil
+	}
+	return t, nil
+}
+
+// RoundTrip implements the RoundTripper interface.
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// RoundTripper 不应修改原请求
+	req = req.Clone(req.Context())
+
+	if t.userAgent != "" {
+		req.Header.Set("User-Agent", t.userAgent)
+	}
+
+	host := req.URL.Hostname()
+	authorized := false
+	for _, h := range t.hosts {
+		if !network.MatchHost(h.Host, host) {
+			continue
+		}
+
+		for k, v := range h.Headers {
+			req.Header.Set(k, v)
+		}
+		switch {
+		case h.BearerToken != "":
+			req.Header.Set("Authorization", "Bearer "+h.BearerToken)
+			authorized = true
+		case h.BearerTokenEnv != "":
+			token := os.Getenv(h.BearerTokenEnv)
+			if token == "" {
+				return nil, fmt.Errorf("环境变量 %s 未设置，无法访问 %s", h.BearerTokenEnv, host)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+			authorized = true
+		case h.BasicAuth != nil:
+			req.SetBasicAuth(h.BasicAuth.Username, h.BasicAuth.Password)
+			authorized = true
+		}
+		// 只配置了请求头时，认证仍可来自 .netrc
+		break
+	}
+
+	// 配置文件中没有该主机的认证时使用 .netrc
+	if !authorized && req.Header.Get("Authorization") == "" {
+		useDefault := strings.EqualFold(host, originalRequest(req).URL.Hostname())
+		if e, ok := lookupNetrc(t.netrc, host, useDefault); ok && e.login != "" {
+			req.SetBasicAuth(e.login, e.password)
+		}
+	}
+
+	return t.base.RoundTrip(req)
+}
+
+// originalRequest 沿重定向链找到最初的请求
+func originalRequest(req *http.Request) *http.Request {
+	for req.Response != nil && req.Response.Request != nil {
+		req = req.Response.Request
+	}
+	return req
+}
@@ -0,0 +1,100 @@
+package download
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/difyz9/Link2COS/config"
+)
+
+// redirectTransport 模拟源站把请求重定向到另一台主机，记录每个主机收到的 Authorization
+type redirectTransport struct {
+	redirects map[string]string
+	auth      map[string]string
+}
+
+func (t *redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.auth[req.URL.Host] = req.Header.Get("Authorization")
+	resp := &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     make(http.Header),
+		Body:       io.NopCloser(strings.NewReader("ok")),
+		Request:    req,
+	}
+	if to, ok := t.redirects[req.URL.Host]; ok {
+		resp.StatusCode = http.StatusFound
+		resp.Header.Set("Location", to)
+	}
+	return resp, nil
+}
+
+func TestAuthTransportNetrcRedirect(t *testing.T) {
+	netrc := filepath.Join(t.TempDir(), "netrc")
+	data := "machine files.example.org login bob password secret2\ndefault login alice password secret1\n"
+	if err := os.WriteFile(netrc, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		link      string
+		redirects map[string]string
+		want      map[string]bool // 各主机是否收到认证
+	}{
+		{
+			name:      "default 不带到重定向后的其他主机",
+			link:      "https://huggingface.co/model.bin",
+			redirects: map[string]string{"huggingface.co": "https://cdn.example.net/model.bin?X-Amz-Signature=abc"},
+			want:      map[string]bool{"huggingface.co": true, "cdn.example.net": false},
+		},
+		{
+			name:      "同一主机内的重定向沿用 default",
+			link:      "https://huggingface.co/a",
+			redirects: map[string]string{"huggingface.co": "https://huggingface.co/b"},
+			want:      map[string]bool{"huggingface.co": true},
+		},
+		{
+			name:      "重定向到有 machine 条目的主机使用该主机的登录信息",
+			link:      "https://huggingface.co/model.bin",
+			redirects: map[string]string{"huggingface.co": "https://files.example.org/model.bin"},
+			want:      map[string]bool{"huggingface.co": true, "files.example.org": true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := &redirectTransport{redirects: tt.redirects, auth: make(map[string]string)}
+			rt, err := newAuthTransport(base, config.SourcesConfig{Netrc: true, NetrcFile: netrc})
+			if err != nil {
+				t.Fatal(err)
+			}
+			client := &http.Client{Transport: rt}
+			// 同一主机内的重定向只跟随一次，避免循环
+			client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+				if len(via) > 1 {
+					return http.ErrUseLastResponse
+				}
+				return nil
+			}
+
+			resp, err := client.Get(tt.link)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+
+			for host, want := range tt.want {
+				got, ok := base.auth[host]
+				if !ok {
+					t.Fatalf("%s 未收到请求", host)
+				}
+				if (got != "") != want {
+					t.Errorf("%s 收到的 Authorization = %q，应%s认证", host, got, map[bool]string{true: "带", false: "不带"}[want])
+				}
+			}
+		})
+	}
+}
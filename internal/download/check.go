@@ -0,0 +1,128 @@
+package download
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/difyz9/Link2COS/config"
+)
+
+// CheckResult 链接健康检查的结果
+type CheckResult struct {
+	Link         string
+	StatusCode   int    // 最终响应的状态码，请求失败时为 0
+	FinalURL     string // 跟随重定向后的地址（隐藏查询参数，可能含签名），没有重定向时为空
+	Redirects    int    // 重定向次数
+	Size         int64  // 文件大小，-1 表示未知
+	AcceptRanges bool   // 是否支持 Range 请求（断点续传、分段下载）
+	Method       string // 得出结果的请求方法: HEAD，或只请求第一个字节的 GET
+	Err          error  // 请求失败（连接、TLS、超时等）
+}
+
+// OK 状态码是否为 2xx
+func (r *CheckResult) OK() bool {
+	return r.Err == nil && r.StatusCode >= 200 && r.StatusCode < 300
+}
+
+// Check 检查链接是否可用，不下载内容
+// 先发送 HEAD 请求；服务端不支持 HEAD、或未声明是否支持 Range 时，改用只请求第一个字节的 GET
+func (d *Downloader) Check(ctx context.Context, link string) *CheckResult {
+	result := &CheckResult{Link: link, Size: -1, Method: http.MethodHead}
+
+	resp, err := d.do(ctx, http.MethodHead, link)
+	if err == nil {
+		resp.Body.Close()
+		result.fill(resp)
+		if resp.StatusCode == http.StatusOK && resp.Header.Get("Accept-Ranges") != "" {
+			result.AcceptRanges = strings.EqualFold(resp.Header.Get("Accept-Ranges"), "bytes")
+			return result
+		}
+	}
+
+	// HEAD 失败或信息不全时用 Range GET 确认
+	rangeResp, rangeErr := d.doRange(ctx, link, 0, 0)
+	if rangeErr != nil {
+		// HEAD 有响应时以 HEAD 的结果为准
+		if err != nil {
+			result.Err = redactRedirectError(rangeErr, link)
+		}
+		return result
+	}
+	defer rangeResp.Body.Close()
+	if result.StatusCode == http.StatusOK && rangeResp.StatusCode >= 300 {
+		return result
+	}
+
+	headSize := result.Size
+	*result = CheckResult{Link: link, Size: -1, Method: http.MethodGet}
+	result.fill(rangeResp)
+	switch rangeResp.StatusCode {
+	case http.StatusPartialContent:
+		result.AcceptRanges = true
+		result.Size = contentRangeTotal(rangeResp.Header.Get("Content-Range"))
+		if result.Size < 0 {
+			result.Size = headSize
+		}
+	case http.StatusOK:
+		// 服务端忽略了 Range，返回完整内容
+		if result.Size < 0 {
+			result.Size = headSize
+		}
+	}
+	return result
+}
+
+// fill 从响应中读取状态码、重定向和大小
+func (r *CheckResult) fill(resp *http.Response) {
+	r.StatusCode = resp.StatusCode
+	for prev := resp.Request.Response; prev != nil; prev = prev.Request.Response {
+		r.Redirects++
+	}
+	if r.Redirects > 0 {
+		r.FinalURL = config.RedactQuery(resp.Request.URL.String())
+	}
+	if resp.StatusCode == http.StatusOK {
+		r.Size = declaredSize(resp)
+	}
+}
+
+// redactRedirectError 请求重定向后的地址失败时，错误中的地址同样隐藏查询参数
+func redactRedirectError(err error, link string) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) && urlErr.URL != link {
+		urlErr.URL = config.RedactQuery(urlErr.URL)
+	}
+	return err
+}
+
+// doRange 发送请求 [start, end] 字节的 GET，ctx 取消时请求中止
+func (d *Downloader) doRange(ctx context.Context, link string, start, end int64) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", start, end))
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求失败: %w", err)
+	}
+	return resp, nil
+}
+
+// contentRangeTotal 解析 Content-Range（bytes 0-0/12345）中的总大小，未知时返回 -1
+func contentRangeTotal(header string) int64 {
+	i := strings.LastIndex(header, "/")
+	if i < 0 {
+		return -1
+	}
+	size, err := strconv.ParseInt(header[i+1:], 10, 64)
+	if err != nil || size < 0 {
+		return -1
+	}
+	return size
+}
@@ -0,0 +1,36 @@
+package download
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCheckRedactsFinalURL(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/model.bin", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/cdn/model.bin?X-Amz-Signature=secret&X-Amz-Credential=key", http.StatusFound)
+	})
+	mux.HandleFunc("/cdn/model.bin", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Accept-Ranges", "bytes")
+		w.Header().Set("Content-Length", "4")
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	r := NewDownloader(srv.Client(), "").Check(context.Background(), srv.URL+"/model.bin")
+	if !r.OK() {
+		t.Fatalf("检查失败: %d %v", r.StatusCode, r.Err)
+	}
+	if r.Redirects != 1 {
+		t.Errorf("重定向 %d 次，应为 1 次", r.Redirects)
+	}
+	if strings.Contains(r.FinalURL, "secret") || strings.Contains(r.FinalURL, "X-Amz") {
+		t.Errorf("FinalURL 包含签名: %s", r.FinalURL)
+	}
+	if want := srv.URL + "/cdn/model.bin?***"; r.FinalURL != want {
+		t.Errorf("FinalURL = %q，应为 %q", r.FinalURL, want)
+	}
+}
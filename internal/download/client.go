@@ -3,33 +3,63 @@ package download
 import (
 	"fmt"
 	"net/http"
-	"net/url"
 	"time"
 
 	"github.com/difyz9/Link2COS/config"
+	"github.com/difyz9/Link2COS/internal/console"
+	"github.com/difyz9/Link2COS/internal/network"
+	"github.com/difyz9/Link2COS/internal/ratelimit"
 )
 
 // CreateHTTPClient 创建支持代理的 HTTP 客户端（用于下载文件）
-func CreateHTTPClient(cfg *config.Config) *http.Client {
+// 代理、TLS 或认证配置无法加载时返回错误，不会静默退回到默认设置
+// limits 为整个运行共享的限速器，nil 表示不限速
+func CreateHTTPClient(cfg *config.Config, limits *ratelimit.Limits) (*http.Client, error) {
 	transport := &http.Transport{
 		MaxIdleConns:        100,
 		MaxIdleConnsPerHost: 10,
 		IdleConnTimeout:     90 * time.Second,
 	}
+	// 分阶段超时：连接、TLS 握手和响应头，下载数据不设总时长
+	network.ApplyTimeouts(transport, cfg.Network.Timeouts)
 
-	// 如果配置了代理，设置代理（用于下载海外文件）
-	if cfg.COS.Proxy != "" {
-		proxy, err := url.Parse(cfg.COS.Proxy)
-		if err != nil {
-			fmt.Printf("警告: 代理URL解析失败: %v\n", err)
-		} else {
-			transport.Proxy = http.ProxyURL(proxy)
-			fmt.Printf("✓ 下载使用代理: %s\n", cfg.COS.Proxy)
-		}
+	// 按主机选择代理：下载海外文件走代理，内网镜像等可以直连
+	proxy, err := network.NewSourceProxy(cfg)
+	if err != nil {
+		return nil, err
+	}
+	transport.Proxy = proxy.Proxy
+	if cfg.Network.SourceProxy != "" {
+		console.Printf("✓ 下载使用代理: %s\n", config.RedactURL(cfg.Network.SourceProxy))
+	}
+
+	// 自定义 CA、客户端证书和公钥固定
+	tlsConfig, err := network.NewTLSConfig(cfg.Network.SourceTLS, proxy.Proxies())
+	if err != nil {
+		return nil, fmt.Errorf("下载源 TLS 配置: %w", err)
+	}
+	if tlsConfig != nil {
+		transport.TLSClientConfig = tlsConfig
+		// 自定义 TLSClientConfig 后需要显式启用 HTTP/2
+		transport.ForceAttemptHTTP2 = true
 	}
 
-	return &http.Client{
-		Transport: transport,
-		Timeout:   300 * time.Second, // 5分钟超时
+	// 下载停滞（长时间没有数据或速度过低）时中止，由下载器重试
+	watched, err := network.WatchDownload(transport, cfg.Network.Timeouts)
+	if err != nil {
+		return nil, err
 	}
+
+	// 按主机附加认证和请求头
+	rt, err := newAuthTransport(limits.WrapDownload(watched), cfg.Sources)
+	if err != nil {
+		return nil, err
+	}
+	for _, h := range cfg.Sources.Hosts {
+		if h.BearerToken != "" || h.BearerTokenEnv != "" || h.BasicAuth != nil {
+			console.Printf("✓ 下载源认证: %s\n", h.Host)
+		}
+	}
+
+	return &http.Client{Transport: rt}, nil
 }
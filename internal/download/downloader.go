@@ -1,12 +1,21 @@
 package download
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"path/filepath"
-	"strings"
+	"sync"
+	"time"
+
+	"github.com/difyz9/Link2COS/internal/checksum"
+	"github.com/difyz9/Link2COS/internal/console"
+	"github.com/difyz9/Link2COS/internal/constants"
+	"github.com/difyz9/Link2COS/internal/events"
+	"github.com/difyz9/Link2COS/internal/progress"
+	"github.com/difyz9/Link2COS/internal/util"
 )
 
 // Result 下载结果
@@ -14,6 +23,7 @@ type Result struct {
 	Link      string
 	LocalPath string
 	Size      int64
+	Checksums checksum.Sums
 	Error     error
 }
 
@@ -21,6 +31,13 @@ type Result struct {
 type Downloader struct {
 	httpClient *http.Client
 	outputDir  string
+	layout     Layout
+	urlPrefix  string
+	onConflict ConflictPolicy
+	progress   *progress.Progress
+
+	mu      sync.Mutex
+	claimed map[string]string // 本次运行已占用的本地路径 -> 链接
 }
 
 // NewDownloader 创建下载器
@@ -28,81 +45,146 @@ func NewDownloader(httpClient *http.Client, outputDir string) *Downloader {
 	return &Downloader{
 		httpClient: httpClient,
 		outputDir:  outputDir,
+		layout:     LayoutFlat,
+		onConflict: ConflictError,
+		claimed:    make(map[string]string),
 	}
 }
 
-// DownloadFile 下载单个文件到本地
-func (d *Downloader) DownloadFile(link string) (*Result, error) {
-	result := &Result{Link: link}
+// SetLayout 设置本地目录布局，mirror 布局需要提供 url_prefix
+func (d *Downloader) SetLayout(layout Layout, urlPrefix string) {
+	d.layout = layout
+	d.urlPrefix = urlPrefix
+}
 
-	// 先获取文件大小
-	fileSize, err := d.getRemoteFileSize(link)
-	if err != nil {
-		result.Error = fmt.Errorf("获取文件大小失败: %w", err)
-		return result, result.Error
+// SetConflictPolicy 设置本地路径冲突策略
+func (d *Downloader) SetConflictPolicy(policy ConflictPolicy) {
+	d.onConflict = policy
+}
+
+// SetProgress 设置进度显示，下载的字节数计入其中
+func (d *Downloader) SetProgress(p *progress.Progress) {
+	d.progress = p
+}
+
+// DownloadFile 下载单个文件到本地，大小不符或连接提前断开时自动重试
+func (d *Downloader) DownloadFile(ctx context.Context, link string) (*Result, error) {
+	var result *Result
+	for attempt := 1; ; attempt++ {
+		result = d.downloadFileOnce(ctx, link)
+		if result.Error == nil || !IsRetryable(result.Error) || attempt >= constants.MaxDownloadAttempts {
+			break
+		}
+
+		console.Printf("  重试 (%d/%d): %v\n", attempt, constants.MaxDownloadAttempts-1, result.Error)
+		if err := util.Sleep(ctx, time.Duration(attempt)*constants.RetryBackoff); err != nil {
+			result.Error = err
+			break
+		}
 	}
-	result.Size = fileSize
 
-	fmt.Printf("  文件大小: %.2f MB\n", float64(fileSize)/(1024*1024))
+	return result, result.Error
+}
+
+// downloadFileOnce 下载单个文件到本地（不重试）
+func (d *Downloader) downloadFileOnce(ctx context.Context, link string) *Result {
+	result := &Result{Link: link}
 
-	// 下载文件
-	resp, err := d.httpClient.Get(link)
+	// 下载文件（文件大小取自 GET 响应，不额外发送 HEAD 请求）
+	resp, fileSize, err := d.get(ctx, link)
 	if err != nil {
-		result.Error = fmt.Errorf("下载失败: %w", err)
-		return result, result.Error
+		result.Error = err
+		return result
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		result.Error = fmt.Errorf("HTTP状态码: %d", resp.StatusCode)
-		return result, result.Error
-	}
-
 	// 确定本地保存路径
-	localPath, err := d.getLocalPath(link)
+	localPath, err := d.getLocalPath(link, remoteFilename(resp, link))
 	if err != nil {
 		result.Error = fmt.Errorf("确定本地路径失败: %w", err)
-		return result, result.Error
+		return result
+	}
+
+	// 检测路径冲突
+	localPath, err = d.claimLocalPath(link, localPath)
+	if err != nil {
+		result.Error = err
+		return result
 	}
 	result.LocalPath = localPath
 
+	file := d.progress.Start(link, filepath.Base(localPath), fileSize, progress.Download)
+	defer file.Close()
+	resp.Body = countingBody(resp.Body, file)
+
 	// 确保目录存在
 	if err := os.MkdirAll(filepath.Dir(localPath), 0755); err != nil {
 		result.Error = fmt.Errorf("创建目录失败: %w", err)
-		return result, result.Error
+		return result
 	}
 
-	// 保存到本地文件
-	file, err := os.Create(localPath)
+	// 写入临时文件，校验大小后再重命名到目标路径
+	reader := newVerifyingReader(resp.Body, fileSize)
+	written, err := writeFileAtomic(localPath, reader, fileSize)
 	if err != nil {
-		result.Error = fmt.Errorf("创建本地文件失败: %w", err)
-		return result, result.Error
+		result.Error = err
+		return result
 	}
-	defer file.Close()
+	result.Size = written
+	result.Checksums = reader.Checksums()
+
+	console.Printf("  保存路径: %s\n", localPath)
+	return result
+}
 
-	// 复制数据
-	_, err = io.Copy(file, resp.Body)
+// DownloadForUpload 下载文件用于上传（返回数据流，大小未知时 Size 为 -1）
+// 返回的数据流读到结尾时会校验字节数，不一致时返回 SizeMismatchError
+func (d *Downloader) DownloadForUpload(ctx context.Context, link string) (*Stream, error) {
+	resp, fileSize, err := d.get(ctx, link)
 	if err != nil {
-		result.Error = fmt.Errorf("保存文件失败: %w", err)
-		return result, result.Error
+		return nil, err
 	}
 
-	fmt.Printf("  保存路径: %s\n", localPath)
-	return result, nil
+	// 同一个进度条继续统计上传的字节数
+	file := d.progress.Start(link, remoteFilename(resp, link), fileSize, progress.Download|progress.Upload)
+	body := countingBody(resp.Body, file)
+
+	return &Stream{verifyingReader: newVerifyingReader(body, fileSize), Size: fileSize, Progress: file}, nil
 }
 
-// DownloadForUpload 下载文件用于上传（返回Reader和大小）
-func (d *Downloader) DownloadForUpload(link string) (io.ReadCloser, int64, error) {
-	// 先获取文件大小
-	fileSize, err := d.getRemoteFileSize(link)
-	if err != nil {
-		return nil, 0, fmt.Errorf("获取文件大小失败: %w", err)
+// Stream 用于上传的下载数据流
+type Stream struct {
+	*verifyingReader
+	Size     int64          // 服务端声明的大小，-1 表示未知（例如 chunked 传输）
+	Progress *progress.File // 该文件的进度，上传时继续使用；未设置进度显示时为 nil
+}
+
+// Close 关闭数据流并结束该文件的进度
+func (s *Stream) Close() error {
+	s.Progress.Close()
+	return s.verifyingReader.Close()
+}
+
+// countingBody 在响应体上统计下载的字节数
+func countingBody(body io.ReadCloser, file *progress.File) io.ReadCloser {
+	if file == nil {
+		return body
 	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{file.DownloadReader(body), body}
+}
 
-	fmt.Printf("  文件大小: %.2f MB\n", float64(fileSize)/(1024*1024))
+// BytesRead 返回已读取的字节数，大小未知时读完后即为文件实际大小
+func (s *Stream) BytesRead() int64 {
+	return s.read
+}
 
-	// 下载文件
-	resp, err := d.httpClient.Get(link)
+// get 发送 GET 请求并从响应中获取文件大小（Content-Length 或 X-Linked-Size），未知时为 -1
+// ctx 取消时请求和之后对响应体的读取都会中止
+func (d *Downloader) get(ctx context.Context, link string) (*http.Response, int64, error) {
+	resp, err := d.do(ctx, http.MethodGet, link)
 	if err != nil {
 		return nil, 0, fmt.Errorf("下载失败: %w", err)
 	}
@@ -112,42 +194,36 @@ func (d *Downloader) DownloadForUpload(link string) (io.ReadCloser, int64, error
 		return nil, 0, fmt.Errorf("HTTP状态码: %d", resp.StatusCode)
 	}
 
-	return resp.Body, fileSize, nil
-}
-
-// getRemoteFileSize 获取远程文件大小
-func (d *Downloader) getRemoteFileSize(url string) (int64, error) {
-	resp, err := d.httpClient.Head(url)
+	// 重定向链中的 X-Linked-Size 与最终的 Content-Length 必须一致
+	fileSize, err := expectedSize(declaredSize(resp), resp.ContentLength)
 	if err != nil {
-		return 0, err
+		resp.Body.Close()
+		return nil, 0, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return 0, fmt.Errorf("HTTP状态码: %d", resp.StatusCode)
+	events.Emit("size_known", events.Fields{"link": link, "size": events.Size(fileSize)})
+	if fileSize >= 0 {
+		console.Printf("  文件大小: %.2f MB\n", float64(fileSize)/(1024*1024))
+	} else {
+		console.Println("  文件大小: 未知（流式传输）")
 	}
 
-	return resp.ContentLength, nil
+	return resp, fileSize, nil
 }
 
-// getLocalPath 根据URL确定本地保存路径
-func (d *Downloader) getLocalPath(link string) (string, error) {
-	// 从URL中提取文件名
-	parts := strings.Split(link, "/")
-	if len(parts) == 0 {
-		return "", fmt.Errorf("无效的URL")
-	}
-
-	filename := parts[len(parts)-1]
-	if filename == "" {
-		filename = "downloaded_file"
+// getLocalPath 根据URL和服务端给出的文件名确定本地保存路径
+func (d *Downloader) getLocalPath(link, remoteName string) (string, error) {
+	// 按布局计算相对路径
+	relPath, err := d.relativeLocalPath(link, remoteName)
+	if err != nil {
+		return "", err
 	}
 
 	// 如果有输出目录，使用输出目录
 	if d.outputDir != "" {
-		return filepath.Join(d.outputDir, filename), nil
+		return filepath.Join(d.outputDir, relPath), nil
 	}
 
 	// 否则使用当前目录
-	return filename, nil
+	return relPath, nil
 }
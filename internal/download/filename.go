@@ -0,0 +1,76 @@
+package download
+
+import (
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// remoteFilename 从响应推断服务端给出的文件名
+// 优先使用 Content-Disposition（含 RFC 5987 的 filename*），其次是重定向后最终URL的最后一段；
+// 都没有时返回空字符串，由调用方退回到原始链接
+func remoteFilename(resp *http.Response, link string) string {
+	if name := contentDispositionFilename(resp.Header.Get("Content-Disposition")); name != "" {
+		return name
+	}
+
+	if resp.Request == nil || resp.Request.URL == nil {
+		return ""
+	}
+	final := resp.Request.URL
+	if final.String() == link {
+		return ""
+	}
+	return sanitizeFilename(path.Base(final.Path))
+}
+
+// contentDispositionFilename 解析 Content-Disposition 中的文件名
+// mime.ParseMediaType 会解码 RFC 5987 形式的 filename* 并放到 filename 参数中，且优先于普通 filename
+func contentDispositionFilename(header string) string {
+	if header == "" {
+		return ""
+	}
+
+	_, params, err := mime.ParseMediaType(header)
+	if err != nil {
+		return ""
+	}
+	return sanitizeFilename(params["filename"])
+}
+
+// linkFilename 从原始链接中提取文件名（去掉查询参数）
+func linkFilename(link string) string {
+	if u, err := url.Parse(link); err == nil {
+		return sanitizeFilename(path.Base(u.Path))
+	}
+
+	parts := strings.Split(link, "/")
+	return sanitizeFilename(parts[len(parts)-1])
+}
+
+// sanitizeFilename 清理文件名，去掉目录部分和控制字符，无效时返回空字符串
+func sanitizeFilename(name string) string {
+	// 有些服务端会在文件名中带上 Windows 风格的路径
+	name = strings.ReplaceAll(name, `\`, "/")
+	name = path.Base(strings.TrimSpace(name))
+
+	name = stripControl(name)
+
+	switch name {
+	case "", ".", "..", "/":
+		return ""
+	}
+	return name
+}
+
+// stripControl 去掉控制字符（包括换行和制表符），避免写入文件名和下载记录
+func stripControl(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return -1
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,52 @@
+package download
+
+import "testing"
+
+func TestContentDispositionFilename(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   string
+	}{
+		{"普通文件名", `attachment; filename="model.bin"`, "model.bin"},
+		{"RFC 5987 编码", `attachment; filename*=UTF-8''%E6%A8%A1%E5%9E%8B.bin`, "模型.bin"},
+		{"filename* 优先", `attachment; filename="a.bin"; filename*=UTF-8''b.bin`, "b.bin"},
+		{"相对路径穿越", `attachment; filename="../../etc/passwd"`, "passwd"},
+		{"绝对路径", `attachment; filename="/etc/passwd"`, "passwd"},
+		{"Windows 路径", `attachment; filename="..\..\Windows\win.ini"`, "win.ini"},
+		{"编码的路径穿越", `attachment; filename*=UTF-8''..%2F..%2Fetc%2Fpasswd`, "passwd"},
+		{"只有 ..", `attachment; filename=".."`, ""},
+		{"控制字符", "attachment; filename*=UTF-8''a%0Ab.bin", "ab.bin"},
+		{"没有文件名", `inline`, ""},
+		{"格式错误", `attachment; filename="unterminated`, ""},
+		{"空", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := contentDispositionFilename(tt.header); got != tt.want {
+				t.Errorf("contentDispositionFilename(%q) = %q，应为 %q", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLinkFilename(t *testing.T) {
+	tests := []struct {
+		name string
+		link string
+		want string
+	}{
+		{"普通链接", "https://example.com/files/model.bin", "model.bin"},
+		{"去掉查询参数", "https://example.com/model.bin?token=abc", "model.bin"},
+		{"编码的文件名", "https://example.com/%E6%A8%A1%E5%9E%8B.bin", "模型.bin"},
+		{"以 / 结尾", "https://example.com/files/", "files"},
+		{"只有主机", "https://example.com", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := linkFilename(tt.link); got != tt.want {
+				t.Errorf("linkFilename(%q) = %q，应为 %q", tt.link, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package download
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/difyz9/Link2COS/internal/console"
+	"github.com/difyz9/Link2COS/internal/util"
+)
+
+// Layout 本地保存路径布局
+type Layout string
+
+const (
+	// LayoutFlat 只保留URL最后一段作为文件名
+	LayoutFlat Layout = "flat"
+	// LayoutMirror 保留链接相对 url_prefix 的路径（与 sync 的COS路径一致）
+	LayoutMirror Layout = "mirror"
+)
+
+// ConflictPolicy 本地路径冲突时的处理策略
+type ConflictPolicy string
+
+const (
+	// ConflictError 冲突时报错，不覆盖已有文件
+	ConflictError ConflictPolicy = "error"
+	// ConflictSuffix 冲突时在文件名后追加序号，例如 model_1.safetensors
+	ConflictSuffix ConflictPolicy = "suffix"
+	// ConflictOverwrite 冲突时覆盖已有文件（会打印警告）
+	ConflictOverwrite ConflictPolicy = "overwrite"
+)
+
+// ParseLayout 解析布局名称，空字符串表示默认的 flat
+func ParseLayout(s string) (Layout, error) {
+	switch Layout(strings.ToLower(strings.TrimSpace(s))) {
+	case "", LayoutFlat:
+		return LayoutFlat, nil
+	case LayoutMirror:
+		return LayoutMirror, nil
+	}
+	return "", fmt.Errorf("未知的目录布局: %s（可选: flat, mirror）", s)
+}
+
+// ParseConflictPolicy 解析冲突策略名称，空字符串表示默认的 error
+func ParseConflictPolicy(s string) (ConflictPolicy, error) {
+	switch ConflictPolicy(strings.ToLower(strings.TrimSpace(s))) {
+	case "", ConflictError:
+		return ConflictError, nil
+	case ConflictSuffix:
+		return ConflictSuffix, nil
+	case ConflictOverwrite:
+		return ConflictOverwrite, nil
+	}
+	return "", fmt.Errorf("未知的冲突策略: %s（可选: error, suffix, overwrite）", s)
+}
+
+// relativeLocalPath 根据布局计算链接相对输出目录的路径
+// remoteName 是服务端给出的文件名（Content-Disposition 或重定向后的URL），为空时使用链接中的文件名
+func (d *Downloader) relativeLocalPath(link, remoteName string) (string, error) {
+	if d.layout != LayoutMirror {
+		filename := remoteName
+		if filename == "" {
+			filename = linkFilename(link)
+		}
+		if filename == "" {
+			filename = "downloaded_file"
+		}
+		return filename, nil
+	}
+
+	// mirror 布局：优先使用相对 url_prefix 的路径，不匹配时退回到 host/path
+	rel, err := util.RelativeLinkPath(d.urlPrefix, link)
+	if err != nil {
+		u, perr := url.Parse(link)
+		if perr != nil {
+			return "", fmt.Errorf("无效的URL: %w", perr)
+		}
+		rel = u.Host + u.EscapedPath()
+	}
+	if i := strings.IndexAny(rel, "?#"); i >= 0 {
+		rel = rel[:i]
+	}
+
+	relPath, err := sanitizeRelativePath(rel)
+	if err != nil {
+		return "", err
+	}
+
+	// 目录结构来自链接，文件名以服务端给出的为准
+	if remoteName != "" {
+		relPath = filepath.Join(filepath.Dir(relPath), remoteName)
+	}
+	return relPath, nil
+}
+
+// sanitizeRelativePath 清理相对路径，去掉控制字符，拒绝跳出输出目录的路径
+func sanitizeRelativePath(rel string) (string, error) {
+	var segments []string
+	for _, seg := range strings.Split(rel, "/") {
+		if unescaped, err := url.PathUnescape(seg); err == nil {
+			seg = unescaped
+		}
+		seg = stripControl(seg)
+		switch seg {
+		case "", ".":
+			continue
+		case "..":
+			return "", fmt.Errorf("链接路径包含非法片段: %s", rel)
+		}
+		if strings.ContainsAny(seg, `/\`) {
+			return "", fmt.Errorf("链接路径包含非法片段: %s", rel)
+		}
+		segments = append(segments, seg)
+	}
+
+	if len(segments) == 0 {
+		return "downloaded_file", nil
+	}
+	// 以 / 结尾的链接没有文件名
+	if strings.HasSuffix(rel, "/") {
+		segments = append(segments, "downloaded_file")
+	}
+
+	return filepath.Join(segments...), nil
+}
+
+// claimLocalPath 检测本地路径冲突并按策略处理，返回最终使用的路径
+func (d *Downloader) claimLocalPath(link, localPath string) (string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// 同一链接重复下载（例如重试）沿用已占用的路径
+	if owner, ok := d.claimed[localPath]; ok && owner == link {
+		return localPath, nil
+	}
+
+	if !d.isTaken(localPath) {
+		d.claimed[localPath] = link
+		return localPath, nil
+	}
+
+	switch d.onConflict {
+	case ConflictOverwrite:
+		console.Printf("  警告: 覆盖已存在的本地文件: %s\n", localPath)
+		d.claimed[localPath] = link
+		return localPath, nil
+	case ConflictSuffix:
+		ext := filepath.Ext(localPath)
+		base := strings.TrimSuffix(localPath, ext)
+		for i := 1; ; i++ {
+			candidate := fmt.Sprintf("%s_%d%s", base, i, ext)
+			// 重试时沿用该链接之前改存的路径，不再生成新的序号
+			if owner, ok := d.claimed[candidate]; ok && owner == link {
+				return candidate, nil
+			}
+			if !d.isTaken(candidate) {
+				console.Printf("  警告: 本地路径冲突，改存为: %s\n", candidate)
+				d.claimed[candidate] = link
+				return candidate, nil
+			}
+		}
+	default:
+		if owner, ok := d.claimed[localPath]; ok {
+			return "", fmt.Errorf("本地路径冲突: %s 已被 %s 使用（可用 --on-conflict suffix|overwrite 处理）", localPath, owner)
+		}
+		return "", fmt.Errorf("本地文件已存在: %s（可用 --on-conflict suffix|overwrite 处理）", localPath)
+	}
+}
+
+// isTaken 判断路径是否已被本次运行占用或已存在于磁盘
+func (d *Downloader) isTaken(localPath string) bool {
+	if _, ok := d.claimed[localPath]; ok {
+		return true
+	}
+	_, err := os.Lstat(localPath)
+	return err == nil
+}
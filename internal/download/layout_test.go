@@ -0,0 +1,141 @@
+package download
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestClaimLocalPath(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy ConflictPolicy
+		onDisk bool     // 目标文件已存在于磁盘
+		claims []string // 依次占用同一路径的链接
+		want   []string // 每次得到的文件名，空字符串表示报错
+	}{
+		{
+			name:   "error 策略拒绝第二个链接",
+			policy: ConflictError,
+			claims: []string{"https://a/model", "https://b/model"},
+			want:   []string{"model.bin", ""},
+		},
+		{
+			name:   "error 策略拒绝磁盘上已有的文件",
+			policy: ConflictError,
+			onDisk: true,
+			claims: []string{"https://a/model"},
+			want:   []string{""},
+		},
+		{
+			name:   "同一链接重试沿用原路径",
+			policy: ConflictError,
+			claims: []string{"https://a/model", "https://a/model"},
+			want:   []string{"model.bin", "model.bin"},
+		},
+		{
+			name:   "suffix 策略依次追加序号",
+			policy: ConflictSuffix,
+			claims: []string{"https://a/model", "https://b/model", "https://c/model"},
+			want:   []string{"model.bin", "model_1.bin", "model_2.bin"},
+		},
+		{
+			name:   "suffix 策略重试沿用改存的路径",
+			policy: ConflictSuffix,
+			claims: []string{"https://a/model", "https://b/model", "https://b/model", "https://b/model"},
+			want:   []string{"model.bin", "model_1.bin", "model_1.bin", "model_1.bin"},
+		},
+		{
+			name:   "suffix 策略重试跳过其他链接的序号",
+			policy: ConflictSuffix,
+			claims: []string{"https://a/model", "https://b/model", "https://c/model", "https://c/model"},
+			want:   []string{"model.bin", "model_1.bin", "model_2.bin", "model_2.bin"},
+		},
+		{
+			name:   "suffix 策略避开磁盘上已有的文件",
+			policy: ConflictSuffix,
+			onDisk: true,
+			claims: []string{"https://a/model", "https://a/model"},
+			want:   []string{"model_1.bin", "model_1.bin"},
+		},
+		{
+			name:   "overwrite 策略覆盖",
+			policy: ConflictOverwrite,
+			claims: []string{"https://a/model", "https://b/model"},
+			want:   []string{"model.bin", "model.bin"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			localPath := filepath.Join(dir, "model.bin")
+			if tt.onDisk {
+				if err := os.WriteFile(localPath, nil, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			d := NewDownloader(nil, dir)
+			d.SetConflictPolicy(tt.policy)
+			for i, link := range tt.claims {
+				got, err := d.claimLocalPath(link, localPath)
+				if tt.want[i] == "" {
+					if err == nil {
+						t.Fatalf("第 %d 次: 期望报错，得到 %s", i+1, got)
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatalf("第 %d 次: %v", i+1, err)
+				}
+				if want := filepath.Join(dir, tt.want[i]); got != want {
+					t.Fatalf("第 %d 次: got %s, want %s", i+1, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSanitizeRelativePath(t *testing.T) {
+	tests := []struct {
+		name    string
+		rel     string
+		want    string
+		wantErr bool
+	}{
+		{"普通路径", "a/b/c.bin", filepath.Join("a", "b", "c.bin"), false},
+		{"去掉开头的 /", "/a/b.bin", filepath.Join("a", "b.bin"), false},
+		{"合并多余的 / 和 .", "a//./b.bin", filepath.Join("a", "b.bin"), false},
+		{"解码转义字符", "a%20b/c.bin", filepath.Join("a b", "c.bin"), false},
+		{"以 / 结尾", "a/b/", filepath.Join("a", "b", "downloaded_file"), false},
+		{"空路径", "", "downloaded_file", false},
+		{"只有 /", "/", "downloaded_file", false},
+		{"去掉编码的换行", "/a%0Ab/x.bin", filepath.Join("ab", "x.bin"), false},
+		{"去掉制表符和 DEL", "a%09/b%7F.bin", filepath.Join("a", "b.bin"), false},
+		{"只有控制字符的片段被忽略", "a/%0A%0D/b.bin", filepath.Join("a", "b.bin"), false},
+		{"控制字符拼出 ..", "a/.%00./b", "", true},
+		{"路径穿越", "a/../../etc/passwd", "", true},
+		{"编码的 ..", "a/%2e%2e/b", "", true},
+		{"编码的 /", "a/%2F/b", "", true},
+		{"编码的 / 拼出穿越", "..%2Fetc/passwd", "", true},
+		{"反斜杠", `a\..\b`, "", true},
+		{"编码的反斜杠", "a/%5C/b", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := sanitizeRelativePath(tt.rel)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("sanitizeRelativePath(%q) = %q，应返回错误", tt.rel, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("sanitizeRelativePath(%q) 返回错误: %v", tt.rel, err)
+			}
+			if got != tt.want {
+				t.Errorf("sanitizeRelativePath(%q) = %q，应为 %q", tt.rel, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package download
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// netrcEntry .netrc 中一台主机的登录信息，machine 为空表示 default
+type netrcEntry struct {
+	machine  string
+	login    string
+	password string
+}
+
+// netrcPath 返回 .netrc 的路径：配置 > 环境变量 NETRC > ~/.netrc
+func netrcPath(configured string) string {
+	path := configured
+	if path == "" {
+		path = os.Getenv("NETRC")
+	}
+	if path == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, ".netrc")
+		}
+	}
+	if strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, path[2:])
+		}
+	}
+	return path
+}
+
+// readNetrc 读取 .netrc 文件，文件不存在时返回空列表
+func readNetrc(path string) ([]netrcEntry, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("读取 .netrc 失败: %w", err)
+	}
+
+	return parseNetrc(string(data)), nil
+}
+
+// parseNetrc 解析 .netrc 内容，支持 machine、default、login、password，忽略 account 和 macdef 宏
+func parseNetrc(data string) []netrcEntry {
+	var entries []netrcEntry
+	var current *netrcEntry
+
+	lines := strings.Split(data, "\n")
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		for j := 0; j < len(fields); j++ {
+			next := func() string {
+				if j+1 < len(fields) {
+					j++
+					return fields[j]
+				}
+				return ""
+			}
+
+			switch fields[j] {
+			case "machine":
+				entries = append(entries, netrcEntry{machine: strings.ToLower(next())})
+				current = &entries[len(entries)-1]
+			case "default":
+				entries = append(entries, netrcEntry{})
+				current = &entries[len(entries)-1]
+			case "login":
+				if login := next(); current != nil {
+					current.login = login
+				}
+			case "password":
+				if password := next(); current != nil {
+					current.password = password
+				}
+			case "account":
+				next()
+			case "macdef":
+				// 宏定义持续到空行为止
+				for i+1 < len(lines) && strings.TrimSpace(lines[i+1]) != "" {
+					i++
+				}
+				j = len(fields)
+			}
+		}
+	}
+
+	return entries
+}
+
+// lookupNetrc 查找主机的登录信息，没有匹配的 machine 且 useDefault 为 true 时使用 default
+func lookupNetrc(entries []netrcEntry, host string, useDefault bool) (netrcEntry, bool) {
+	host = strings.ToLower(host)
+	for _, e := range entries {
+		if e.machine != "" && e.machine == host {
+			return e, true
+		}
+	}
+	if !useDefault {
+		return netrcEntry{}, false
+	}
+	for _, e := range entries {
+		if e.machine == "" {
+			return e, true
+		}
+	}
+	return netrcEntry{}, false
+}
@@ -0,0 +1,88 @@
+package download
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseNetrc(t *testing.T) {
+	const multi = `# 注释行
+machine example.com login alice password secret1
+
+machine Files.Example.org
+  login bob
+  password secret2
+  account ignored
+
+macdef init
+  cd /pub
+  machine evil.com login mallory password stolen
+
+default login anonymous password guest
+`
+	tests := []struct {
+		name         string
+		data         string
+		host         string
+		wantOK       bool
+		wantLogin    string
+		wantPassword string
+	}{
+		{"单行", multi, "example.com", true, "alice", "secret1"},
+		{"分多行并忽略 account", multi, "files.example.org", true, "bob", "secret2"},
+		{"主机名大小写不敏感", multi, "EXAMPLE.com", true, "alice", "secret1"},
+		{"macdef 中的内容被忽略", multi, "evil.com", true, "anonymous", "guest"},
+		{"没有匹配时使用 default", multi, "other.com", true, "anonymous", "guest"},
+		{"default 写在前面也不抢先匹配", "default login anon password x\nmachine a.com login u password p\n", "a.com", true, "u", "p"},
+		{"没有 default 时不匹配", "machine a.com login u password p\n", "b.com", false, "", ""},
+		{"只有登录名", "machine a.com login u\n", "a.com", true, "u", ""},
+		{"子域名不匹配", "machine example.com login u password p\n", "cdn.example.com", false, "", ""},
+		{"machine 之前的 login 被忽略", "login stray password x\nmachine a.com login u password p\n", "a.com", true, "u", "p"},
+		{"空文件", "", "a.com", false, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry, ok := lookupNetrc(parseNetrc(tt.data), tt.host, true)
+			if ok != tt.wantOK {
+				t.Fatalf("查找 %s 结果 %v，应为 %v", tt.host, ok, tt.wantOK)
+			}
+			if entry.login != tt.wantLogin || entry.password != tt.wantPassword {
+				t.Errorf("查找 %s 得到 %q/%q，应为 %q/%q", tt.host, entry.login, entry.password, tt.wantLogin, tt.wantPassword)
+			}
+		})
+	}
+}
+
+func TestReadNetrcMissingFile(t *testing.T) {
+	entries, err := readNetrc(filepath.Join(t.TempDir(), "missing"))
+	if err != nil || entries != nil {
+		t.Errorf("文件不存在时应返回空列表，得到 %v, %v", entries, err)
+	}
+}
+
+func TestNetrcPath(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("无法获取主目录")
+	}
+	tests := []struct {
+		name       string
+		configured string
+		env        string
+		want       string
+	}{
+		{"配置优先", "/etc/netrc", "/tmp/netrc", "/etc/netrc"},
+		{"环境变量", "", "/tmp/netrc", "/tmp/netrc"},
+		{"默认主目录", "", "", filepath.Join(home, ".netrc")},
+		{"展开 ~/", "~/auth/netrc", "", filepath.Join(home, "auth", "netrc")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("NETRC", tt.env)
+			if got := netrcPath(tt.configured); got != tt.want {
+				t.Errorf("netrcPath(%q) = %q，应为 %q", tt.configured, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package download
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// ProbeResult 不下载内容时获得的文件信息
+type ProbeResult struct {
+	Size     int64  // 文件大小，-1 表示未知
+	Filename string // 服务端给出的文件名，没有时为空
+}
+
+// Probe 用 HEAD 请求获取文件大小和文件名；服务端不支持 HEAD 时改用 GET 并只读取响应头
+func (d *Downloader) Probe(ctx context.Context, link string) (*ProbeResult, error) {
+	resp, err := d.do(ctx, http.MethodHead, link)
+	if err == nil && resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		resp, err = d.do(ctx, http.MethodGet, link)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("探测失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP状态码: %d", resp.StatusCode)
+	}
+
+	size, err := expectedSize(declaredSize(resp), resp.ContentLength)
+	if err != nil {
+		return nil, err
+	}
+	return &ProbeResult{Size: size, Filename: remoteFilename(resp, link)}, nil
+}
+
+// PlanLocalPath 计算链接的本地保存路径并按冲突策略处理（只在内存中占用路径，不写入磁盘）
+func (d *Downloader) PlanLocalPath(link, remoteName string) (string, error) {
+	localPath, err := d.getLocalPath(link, remoteName)
+	if err != nil {
+		return "", fmt.Errorf("确定本地路径失败: %w", err)
+	}
+	return d.claimLocalPath(link, localPath)
+}
+
+// do 发送请求，ctx 取消时请求中止
+func (d *Downloader) do(ctx context.Context, method, link string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, link, nil)
+	if err != nil {
+		return nil, err
+	}
+	return d.httpClient.Do(req)
+}
@@ -0,0 +1,264 @@
+package download
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/difyz9/Link2COS/internal/checksum"
+	"github.com/difyz9/Link2COS/internal/console"
+	"github.com/difyz9/Link2COS/internal/constants"
+	"github.com/difyz9/Link2COS/internal/progress"
+	"github.com/difyz9/Link2COS/internal/util"
+)
+
+// stateSuffix 分段下载的续传记录后缀，位于 .part 文件旁
+const stateSuffix = ".state"
+
+// RangedFile 分段下载的文件，大小必须已知
+type RangedFile struct {
+	Link      string // 下载地址
+	LocalPath string // 本地保存路径
+	Size      int64
+	Version   string // 文件版本（例如 ETag），与续传记录一致时才续传；为空时不续传
+	CRC64     string // 期望的 CRC64，为空时不校验
+}
+
+// ChecksumMismatchError 下载完成的文件与期望的校验值不一致
+type ChecksumMismatchError struct {
+	Expected string
+	Actual   string
+}
+
+func (e *ChecksumMismatchError) Error() string {
+	return fmt.Sprintf("CRC64 校验失败: 期望 %s，实际 %s", e.Expected, e.Actual)
+}
+
+// rangeState 续传记录：已写入并落盘的分段
+type rangeState struct {
+	Version   string `json:"version"`
+	Size      int64  `json:"size"`
+	ChunkSize int64  `json:"chunk_size"`
+	Done      []int  `json:"done"`
+}
+
+// DownloadRanged 用并发的 Range 请求下载文件：各分段直接写入 .part 文件的对应位置，
+// 完成的分段记录在 .part.state 中，失败或中断后再次下载同一版本时只请求剩余的分段。
+// 全部分段完成后校验 CRC64，一致才重命名为目标文件；校验失败时删除临时文件
+func (d *Downloader) DownloadRanged(ctx context.Context, f RangedFile) (*Result, error) {
+	result := &Result{Link: f.Link, LocalPath: f.LocalPath, Size: f.Size}
+	if err := os.MkdirAll(filepath.Dir(f.LocalPath), 0755); err != nil {
+		return result, fmt.Errorf("创建目录失败: %w", err)
+	}
+	partPath := f.LocalPath + partSuffix
+	statePath := partPath + stateSuffix
+
+	state := loadRangeState(statePath, partPath, f)
+	file, err := os.OpenFile(partPath, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return result, fmt.Errorf("创建临时文件失败: %w", err)
+	}
+	if err := file.Truncate(f.Size); err != nil {
+		file.Close()
+		return result, fmt.Errorf("创建临时文件失败: %w", err)
+	}
+
+	chunks := int((f.Size + state.ChunkSize - 1) / state.ChunkSize)
+	done := make(map[int]bool, len(state.Done))
+	var resumed int64
+	for _, i := range state.Done {
+		if i >= 0 && i < chunks && !done[i] {
+			done[i] = true
+			start, end := state.chunk(i)
+			resumed += end - start + 1
+		}
+	}
+	var pending []int
+	for i := 0; i < chunks; i++ {
+		if !done[i] {
+			pending = append(pending, i)
+		}
+	}
+	if resumed > 0 {
+		console.Printf("  续传: 已完成 %d/%d 段（%.2f MB）\n", len(done), chunks, float64(resumed)/(1024*1024))
+	}
+
+	prog := d.progress.Start(f.Link, filepath.Base(f.LocalPath), f.Size, progress.Download)
+	defer prog.Close()
+	prog.AddDownloaded(resumed)
+
+	// 失败或中断时保留 .part 和续传记录
+	err = d.fetchRanges(ctx, file, f.Link, state, statePath, pending, prog)
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return result, err
+	}
+
+	sums, err := checksum.File(partPath)
+	if err != nil {
+		return result, fmt.Errorf("计算校验值失败: %w", err)
+	}
+	if f.CRC64 != "" && sums.CRC64 != f.CRC64 {
+		os.Remove(partPath)
+		os.Remove(statePath)
+		return result, &ChecksumMismatchError{Expected: f.CRC64, Actual: sums.CRC64}
+	}
+
+	if err := os.Rename(partPath, f.LocalPath); err != nil {
+		return result, fmt.Errorf("重命名临时文件失败: %w", err)
+	}
+	os.Remove(statePath)
+	syncDir(filepath.Dir(f.LocalPath))
+
+	result.Checksums = sums
+	return result, nil
+}
+
+// fetchRanges 并发下载各分段，每完成一段落盘并更新续传记录；任一分段失败时取消其余分段
+func (d *Downloader) fetchRanges(ctx context.Context, file *os.File, link string, state *rangeState, statePath string, pending []int, prog *progress.File) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var mu sync.Mutex
+	var firstErr error
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < constants.MaxConcurrentRanges; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				start, end := state.chunk(i)
+				err := d.fetchChunk(ctx, file, link, start, end, prog)
+
+				mu.Lock()
+				if err == nil {
+					// 先落盘再记录，记录中的分段在断电后也是完整的
+					if err = file.Sync(); err == nil {
+						state.Done = append(state.Done, i)
+						err = state.save(statePath)
+					}
+				}
+				if err != nil && firstErr == nil {
+					firstErr = err
+					cancel()
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+feed:
+	for _, i := range pending {
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	if firstErr == nil {
+		firstErr = ctx.Err()
+	}
+	return firstErr
+}
+
+// fetchChunk 下载 [start, end] 分段写入文件，连接中断、大小不符等可重试的错误会重试
+func (d *Downloader) fetchChunk(ctx context.Context, file *os.File, link string, start, end int64, prog *progress.File) error {
+	for attempt := 1; ; attempt++ {
+		n, err := d.fetchChunkOnce(ctx, file, link, start, end, prog)
+		if err == nil {
+			return nil
+		}
+		// 失败的分段会重新下载，撤销已计入进度的字节数
+		prog.AddDownloaded(-n)
+		if !IsRetryable(err) || attempt >= constants.MaxDownloadAttempts {
+			return err
+		}
+
+		console.Printf("  分段 %d-%d 重试 (%d/%d): %v\n", start, end, attempt, constants.MaxDownloadAttempts-1, err)
+		if err := util.Sleep(ctx, time.Duration(attempt)*constants.RetryBackoff); err != nil {
+			return err
+		}
+	}
+}
+
+// fetchChunkOnce 下载一个分段（不重试），返回写入的字节数
+func (d *Downloader) fetchChunkOnce(ctx context.Context, file *os.File, link string, start, end int64, prog *progress.File) (int64, error) {
+	resp, err := d.doRange(ctx, link, start, end)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusPartialContent {
+		return 0, fmt.Errorf("分段请求 HTTP状态码: %d", resp.StatusCode)
+	}
+
+	want := end - start + 1
+	reader := io.LimitReader(prog.DownloadReader(resp.Body), want)
+	written, err := io.Copy(io.NewOffsetWriter(file, start), reader)
+	if err != nil {
+		return written, fmt.Errorf("下载分段失败: %w", err)
+	}
+	if written != want {
+		return written, &SizeMismatchError{Expected: want, Actual: written}
+	}
+	return written, nil
+}
+
+// loadRangeState 读取续传记录；记录不存在、版本或大小不一致、临时文件不完整时从头开始
+func loadRangeState(statePath, partPath string, f RangedFile) *rangeState {
+	fresh := &rangeState{Version: f.Version, Size: f.Size, ChunkSize: constants.RangeChunkSize}
+	if f.Version == "" {
+		return fresh
+	}
+
+	data, err := os.ReadFile(statePath)
+	if err != nil {
+		return fresh
+	}
+	var state rangeState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return fresh
+	}
+	if state.Version != f.Version || state.Size != f.Size || state.ChunkSize <= 0 {
+		return fresh
+	}
+	if info, err := os.Stat(partPath); err != nil || info.Size() != f.Size {
+		return fresh
+	}
+	return &state
+}
+
+// save 写入续传记录
+func (s *rangeState) save(path string) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("保存续传记录失败: %w", err)
+	}
+	return nil
+}
+
+// chunk 第 i 个分段的字节范围
+func (s *rangeState) chunk(i int) (start, end int64) {
+	start = int64(i) * s.ChunkSize
+	end = min(start+s.ChunkSize, s.Size) - 1
+	return start, end
+}
@@ -0,0 +1,102 @@
+package download
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/difyz9/Link2COS/internal/checksum"
+	"github.com/difyz9/Link2COS/internal/network"
+)
+
+// SizeMismatchError 实际接收的字节数与服务端声明的大小不一致
+// 通常是连接提前断开造成的，可以重试
+type SizeMismatchError struct {
+	Expected int64
+	Actual   int64
+}
+
+func (e *SizeMismatchError) Error() string {
+	return fmt.Sprintf("文件大小不符: 期望 %d 字节，实际 %d 字节", e.Expected, e.Actual)
+}
+
+// IsRetryable 判断错误是否值得重试（大小不符、连接提前断开或传输停滞）
+func IsRetryable(err error) bool {
+	var mismatch *SizeMismatchError
+	var stall *network.StallError
+	return errors.As(err, &mismatch) || errors.As(err, &stall) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// declaredSize 获取响应声明的文件大小，未知时返回 -1
+// Hugging Face 等 LFS 服务会在重定向响应中给出 X-Linked-Size，它比最终响应的 Content-Length 更可靠
+func declaredSize(resp *http.Response) int64 {
+	for r := resp; r != nil; {
+		if v := r.Header.Get("X-Linked-Size"); v != "" {
+			if size, err := strconv.ParseInt(v, 10, 64); err == nil && size >= 0 {
+				return size
+			}
+		}
+		if r.Request == nil {
+			break
+		}
+		r = r.Request.Response
+	}
+
+	if resp.ContentLength >= 0 {
+		return resp.ContentLength
+	}
+	return -1
+}
+
+// expectedSize 合并多个来源声明的大小（-1 表示未知），来源之间不一致时返回可重试的错误
+func expectedSize(sizes ...int64) (int64, error) {
+	expected := int64(-1)
+	for _, size := range sizes {
+		if size < 0 {
+			continue
+		}
+		if expected >= 0 && size != expected {
+			return -1, &SizeMismatchError{Expected: expected, Actual: size}
+		}
+		expected = size
+	}
+	return expected, nil
+}
+
+// verifyingReader 统计读取的字节数并计算校验值，读到结尾时与期望大小比较
+// 不一致时用 SizeMismatchError 代替 io.EOF，避免把截断的数据当作完整文件
+type verifyingReader struct {
+	io.ReadCloser
+	expected int64
+	read     int64
+	hasher   *checksum.Hasher
+}
+
+// newVerifyingReader 包装响应体，expected < 0 时只计数不校验
+func newVerifyingReader(body io.ReadCloser, expected int64) *verifyingReader {
+	return &verifyingReader{ReadCloser: body, expected: expected, hasher: checksum.New()}
+}
+
+// Checksums 返回已读取内容的校验值，读完后即为整个文件的校验值
+func (r *verifyingReader) Checksums() checksum.Sums {
+	return r.hasher.Sums()
+}
+
+func (r *verifyingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.read += int64(n)
+	r.hasher.Write(p[:n])
+
+	if r.expected >= 0 && r.read > r.expected {
+		return n, &SizeMismatchError{Expected: r.expected, Actual: r.read}
+	}
+	if err == io.EOF && r.expected >= 0 && r.read != r.expected {
+		return n, &SizeMismatchError{Expected: r.expected, Actual: r.read}
+	}
+	if errors.Is(err, io.ErrUnexpectedEOF) && r.expected >= 0 {
+		return n, fmt.Errorf("%w（已接收 %d/%d 字节）", err, r.read, r.expected)
+	}
+	return n, err
+}
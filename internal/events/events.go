@@ -0,0 +1,67 @@
+package events
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// Fields 事件的附加字段
+type Fields map[string]interface{}
+
+// 启用后每个事件输出为一行 JSON（NDJSON），供脚本和编排工具解析
+var (
+	mu      sync.Mutex
+	out     io.Writer
+	enabled bool
+)
+
+// Enable 开始向 w 输出事件
+func Enable(w io.Writer) {
+	mu.Lock()
+	defer mu.Unlock()
+	out = w
+	enabled = true
+}
+
+// Enabled 是否输出事件
+func Enabled() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return enabled
+}
+
+// Emit 输出一个事件，未启用时忽略
+// 每行以 event 和 time 开头，其余字段按名称排序
+func Emit(event string, fields Fields) {
+	mu.Lock()
+	defer mu.Unlock()
+	if !enabled {
+		return
+	}
+
+	head, _ := json.Marshal(struct {
+		Event string `json:"event"`
+		Time  string `json:"time"`
+	}{event, time.Now().Format(time.RFC3339Nano)})
+
+	line := head
+	if len(fields) > 0 {
+		body, err := json.Marshal(fields)
+		if err != nil {
+			body, _ = json.Marshal(Fields{"marshal_error": err.Error()})
+		}
+		// 合并两个 JSON 对象：去掉 head 的 "}" 和 body 的 "{"
+		line = append(append(head[:len(head)-1:len(head)-1], ','), body[1:]...)
+	}
+	out.Write(append(line, '\n'))
+}
+
+// Size 大小未知（< 0）时输出 null
+func Size(size int64) interface{} {
+	if size < 0 {
+		return nil
+	}
+	return size
+}
@@ -0,0 +1,229 @@
+package network
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/difyz9/Link2COS/config"
+)
+
+// ProxySelector 按请求的主机选择代理：
+// 按顺序匹配 network.rules，其次 no_proxy 中的主机直连，最后使用默认代理
+type ProxySelector struct {
+	rules    []proxyRule
+	noProxy  []noProxyEntry
+	fallback *url.URL // 默认代理，nil 表示直连
+}
+
+type proxyRule struct {
+	pattern string
+	proxy   *url.URL // nil 表示直连
+}
+
+// noProxyEntry NO_PROXY 中的一项：主机名（含子域名）、IP 或 CIDR，可带端口
+type noProxyEntry struct {
+	all    bool
+	domain string
+	ipNet  *net.IPNet
+	port   string
+}
+
+// NewSourceProxy 创建下载源使用的代理选择器，默认代理为 network.source_proxy（兼容 cos.proxy）
+func NewSourceProxy(cfg *config.Config) (*ProxySelector, error) {
+	proxy := cfg.Network.SourceProxy
+	if proxy == "" {
+		proxy = cfg.COS.Proxy
+	}
+	return newProxySelector(cfg.Network, proxy)
+}
+
+// NewStorageProxy 创建访问COS使用的代理选择器，默认代理为 network.storage_proxy
+func NewStorageProxy(cfg *config.Config) (*ProxySelector, error) {
+	return newProxySelector(cfg.Network, cfg.Network.StorageProxy)
+}
+
+func newProxySelector(n config.NetworkConfig, defaultProxy string) (*ProxySelector, error) {
+	s := &ProxySelector{}
+
+	if defaultProxy != "" {
+		u, err := parseProxy(defaultProxy)
+		if err != nil {
+			return nil, err
+		}
+		s.fallback = u
+	}
+
+	for i, r := range n.Rules {
+		rule := proxyRule{pattern: strings.ToLower(strings.TrimSpace(r.Host))}
+		if !strings.EqualFold(r.Proxy, "direct") {
+			u, err := parseProxy(r.Proxy)
+			if err != nil {
+				return nil, fmt.Errorf("network.rules[%d]: %w", i, err)
+			}
+			rule.proxy = u
+		}
+		s.rules = append(s.rules, rule)
+	}
+
+	noProxy := n.NoProxy
+	if noProxy == "" {
+		noProxy = os.Getenv("NO_PROXY")
+	}
+	if noProxy == "" {
+		noProxy = os.Getenv("no_proxy")
+	}
+	s.noProxy = parseNoProxy(noProxy)
+
+	return s, nil
+}
+
+// parseProxy 解析代理URL，错误信息中不包含代理凭证
+func parseProxy(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("代理URL解析失败: %s", config.RedactURL(raw))
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+		return u, nil
+	}
+	return nil, fmt.Errorf("不支持的代理协议: %s（可选: http, https, socks5, socks5h）", u.Scheme)
+}
+
+// Proxy 用作 http.Transport.Proxy，返回 nil 表示直连
+func (s *ProxySelector) Proxy(req *http.Request) (*url.URL, error) {
+	return s.ProxyFor(hostPort(req.URL)), nil
+}
+
+// hostPort 返回URL的主机和端口，没有写端口时补上协议的默认端口，
+// 使 NO_PROXY 中的 host:443 能匹配 https://host/
+func hostPort(u *url.URL) string {
+	if u.Port() != "" {
+		return u.Host
+	}
+	switch u.Scheme {
+	case "http":
+		return net.JoinHostPort(u.Hostname(), "80")
+	case "https":
+		return net.JoinHostPort(u.Hostname(), "443")
+	}
+	return u.Host
+}
+
+// ProxyFor 返回访问指定主机（可带端口）使用的代理，nil 表示直连
+func (s *ProxySelector) ProxyFor(hostport string) *url.URL {
+	host, port := splitHostPort(hostport)
+
+	for _, r := range s.rules {
+		if MatchHost(r.pattern, host) {
+			return r.proxy
+		}
+	}
+	for _, e := range s.noProxy {
+		if e.match(host, port) {
+			return nil
+		}
+	}
+	return s.fallback
+}
+
+// Describe 返回访问指定主机时使用的代理说明，代理凭证已隐藏
+func (s *ProxySelector) Describe(hostport string) string {
+	if u := s.ProxyFor(hostport); u != nil {
+		return config.RedactURL(u.String())
+	}
+	return "直连"
+}
+
+// Proxies 返回所有配置的代理地址（去重），用于连通性检查
+func (s *ProxySelector) Proxies() []*url.URL {
+	var list []*url.URL
+	seen := make(map[string]bool)
+	add := func(u *url.URL) {
+		if u != nil && !seen[u.String()] {
+			seen[u.String()] = true
+			list = append(list, u)
+		}
+	}
+	add(s.fallback)
+	for _, r := range s.rules {
+		add(r.proxy)
+	}
+	return list
+}
+
+// MatchHost 匹配配置中的主机：example.com 只匹配自身，*.example.com 和 .example.com 匹配子域名（.example.com 也匹配自身）
+func MatchHost(pattern, host string) bool {
+	pattern, host = strings.ToLower(pattern), strings.ToLower(host)
+	switch {
+	case pattern == "*":
+		return true
+	case strings.HasPrefix(pattern, "*."):
+		return strings.HasSuffix(host, pattern[1:])
+	case strings.HasPrefix(pattern, "."):
+		return host == pattern[1:] || strings.HasSuffix(host, pattern)
+	}
+	return host == pattern
+}
+
+// parseNoProxy 按 curl 的 NO_PROXY 语义解析：逗号分隔，* 表示全部直连，
+// 主机名同时匹配其子域名，支持 IP、CIDR 和 host:port
+func parseNoProxy(value string) []noProxyEntry {
+	var entries []noProxyEntry
+	for _, item := range strings.Split(value, ",") {
+		item = strings.ToLower(strings.TrimSpace(item))
+		if item == "" {
+			continue
+		}
+		if item == "*" {
+			entries = append(entries, noProxyEntry{all: true})
+			continue
+		}
+		if _, ipNet, err := net.ParseCIDR(item); err == nil {
+			entries = append(entries, noProxyEntry{ipNet: ipNet})
+			continue
+		}
+
+		var e noProxyEntry
+		host, port := splitHostPort(item)
+		e.port = port
+		if ip := net.ParseIP(host); ip != nil {
+			bits := 8 * net.IPv4len
+			if ip.To4() == nil {
+				bits = 8 * net.IPv6len
+			}
+			e.ipNet = &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}
+		} else {
+			e.domain = strings.TrimPrefix(strings.TrimPrefix(host, "*"), ".")
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+func (e noProxyEntry) match(host, port string) bool {
+	if e.all {
+		return true
+	}
+	if e.port != "" && e.port != port {
+		return false
+	}
+	if e.ipNet != nil {
+		ip := net.ParseIP(host)
+		return ip != nil && e.ipNet.Contains(ip)
+	}
+	return host == e.domain || strings.HasSuffix(host, "."+e.domain)
+}
+
+// splitHostPort 拆分主机和端口，没有端口时 port 为空；主机名转为小写
+func splitHostPort(hostport string) (string, string) {
+	host, port, err := net.SplitHostPort(hostport)
+	if err != nil {
+		host, port = strings.Trim(hostport, "[]"), ""
+	}
+	return strings.ToLower(host), port
+}
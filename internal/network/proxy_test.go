@@ -0,0 +1,111 @@
+package network
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestParseNoProxy(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		hostport string
+		want     bool // 是否直连
+	}{
+		{"全部直连", "*", "example.com:443", true},
+		{"主机名匹配自身", "example.com", "example.com:443", true},
+		{"主机名匹配子域名", "example.com", "cdn.example.com:443", true},
+		{"不匹配相似后缀", "example.com", "badexample.com:443", false},
+		{"开头的 . 匹配自身和子域名", ".example.com", "example.com:80", true},
+		{"开头的 *. 匹配子域名", "*.example.com", "a.b.example.com:80", true},
+		{"大小写不敏感", "Example.COM", "CDN.example.com:443", true},
+		{"逗号分隔和空白", " foo.com , example.com ,", "example.com:443", true},
+		{"列表中都不匹配", "foo.com,bar.com", "example.com:443", false},
+		{"端口相同", "example.com:8080", "example.com:8080", true},
+		{"端口不同", "example.com:8080", "example.com:443", false},
+		{"IPv4 地址", "10.0.0.1", "10.0.0.1:80", true},
+		{"IPv4 地址不匹配", "10.0.0.1", "10.0.0.2:80", false},
+		{"CIDR", "192.168.0.0/16", "192.168.3.4:443", true},
+		{"CIDR 之外", "192.168.0.0/16", "192.169.0.1:443", false},
+		{"CIDR 不匹配主机名", "192.168.0.0/16", "example.com:443", false},
+		{"IPv6 地址", "::1", "[::1]:8080", true},
+		{"IPv6 带端口", "[::1]:8080", "[::1]:9090", false},
+		{"空值", "", "example.com:443", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			host, port := splitHostPort(tt.hostport)
+			got := false
+			for _, e := range parseNoProxy(tt.value) {
+				if e.match(host, port) {
+					got = true
+					break
+				}
+			}
+			if got != tt.want {
+				t.Errorf("NO_PROXY=%q 匹配 %s = %v，应为 %v", tt.value, tt.hostport, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchHost(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		host    string
+		want    bool
+	}{
+		{"精确匹配", "example.com", "example.com", true},
+		{"精确模式不匹配子域名", "example.com", "cdn.example.com", false},
+		{"大小写不敏感", "Example.com", "EXAMPLE.COM", true},
+		{"* 匹配全部", "*", "anything.org", true},
+		{"*. 匹配子域名", "*.example.com", "cdn.example.com", true},
+		{"*. 匹配多级子域名", "*.example.com", "a.b.example.com", true},
+		{"*. 不匹配自身", "*.example.com", "example.com", false},
+		{"*. 不匹配相似后缀", "*.example.com", "badexample.com", false},
+		{". 匹配自身", ".example.com", "example.com", true},
+		{". 匹配子域名", ".example.com", "cdn.example.com", true},
+		{". 不匹配相似后缀", ".example.com", "badexample.com", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MatchHost(tt.pattern, tt.host); got != tt.want {
+				t.Errorf("MatchHost(%q, %q) = %v，应为 %v", tt.pattern, tt.host, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProxySelectorDefaultPort(t *testing.T) {
+	s := &ProxySelector{
+		fallback: &url.URL{Scheme: "http", Host: "proxy.corp:3128"},
+		noProxy:  parseNoProxy("internal.example:443,plain.example:80,alt.example:8443"),
+	}
+	tests := []struct {
+		link   string
+		direct bool
+	}{
+		{"https://internal.example/model.bin", true},
+		{"https://internal.example:443/model.bin", true},
+		{"http://internal.example/model.bin", false},
+		{"http://plain.example/a", true},
+		{"https://plain.example/a", false},
+		{"https://alt.example/a", false},
+		{"https://alt.example:8443/a", true},
+		{"https://[::1]/a", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.link, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, tt.link, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			proxy, _ := s.Proxy(req)
+			if (proxy == nil) != tt.direct {
+				t.Errorf("%s 使用代理 %v，应%s", tt.link, proxy, map[bool]string{true: "直连", false: "走代理"}[tt.direct])
+			}
+		})
+	}
+}
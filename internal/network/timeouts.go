@@ -0,0 +1,279 @@
+package network
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/difyz9/Link2COS/config"
+)
+
+// ApplyTimeouts 在 Transport 上设置连接、TLS 握手和等待响应头的超时
+// 传输数据的阶段不设超时，由 Watch 按停滞情况判断
+func ApplyTimeouts(t *http.Transport, cfg config.TimeoutsConfig) {
+	cfg = cfg.WithDefaults()
+	dialer := &net.Dialer{
+		Timeout:   cfg.Connect,
+		KeepAlive: 30 * time.Second,
+	}
+	t.DialContext = dialer.DialContext
+	t.TLSHandshakeTimeout = cfg.TLSHandshake
+	t.ResponseHeaderTimeout = cfg.ResponseHeader
+}
+
+// StallError 传输停滞：长时间没有数据或平均速度低于下限，可以重试
+type StallError struct {
+	Reason string
+}
+
+func (e *StallError) Error() string {
+	return "传输停滞: " + e.Reason
+}
+
+// watchInterval 检查停滞的间隔
+const watchInterval = time.Second
+
+// WatchDownload 监控下载的响应体，停滞时中止请求，读取返回 StallError
+func WatchDownload(base http.RoundTripper, cfg config.TimeoutsConfig) (http.RoundTripper, error) {
+	return newWatchTransport(base, cfg, false)
+}
+
+// WatchUpload 监控上传的请求体，停滞时中止请求，请求返回 StallError
+func WatchUpload(base http.RoundTripper, cfg config.TimeoutsConfig) (http.RoundTripper, error) {
+	return newWatchTransport(base, cfg, true)
+}
+
+func newWatchTransport(base http.RoundTripper, cfg config.TimeoutsConfig, upload bool) (http.RoundTripper, error) {
+	cfg = cfg.WithDefaults()
+	minSpeed, err := config.ParseRate(cfg.StallMinSpeed)
+	if err != nil {
+		return nil, fmt.Errorf("network.timeouts.stall_min_speed: %w", err)
+	}
+	return &watchTransport{
+		base:     base,
+		idle:     cfg.IdleRead,
+		minSpeed: minSpeed,
+		window:   cfg.StallWindow,
+		upload:   upload,
+	}, nil
+}
+
+// watchTransport 为每个请求创建一个 watchdog，停滞时取消该请求的 context
+type watchTransport struct {
+	base     http.RoundTripper
+	idle     time.Duration
+	minSpeed int64
+	window   time.Duration
+	upload   bool
+}
+
+// RoundTrip implements the RoundTripper interface.
+func (t *watchTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	hasBody := req.Body != nil && req.Body != http.NoBody
+	if t.upload && !hasBody {
+		return t.base.RoundTrip(req)
+	}
+
+	ctx, cancel := context.WithCancel(req.Context())
+	// 下载时读取方可能暂停（例如等待上传腾出分块），只统计 Read 阻塞在连接上的时间；
+	// 上传时请求体由 Transport 读取，两次读取之间正是写入连接的时间，需要一直计时
+	w := &watchdog{idle: t.idle, minSpeed: t.minSpeed, window: t.window, cancel: cancel, pauseBetweenReads: !t.upload}
+	req = req.WithContext(ctx)
+
+	if t.upload {
+		// 请求体读完后（数据已交给连接）停止监控，之后等待响应头由 ResponseHeaderTimeout 负责
+		body := req.Body
+		req.Body = struct {
+			io.Reader
+			io.Closer
+		}{&watchedReader{r: body, w: w}, body}
+		w.start()
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if t.upload {
+		w.stop()
+	}
+	if err != nil {
+		cancel()
+		if stall := w.Err(); stall != nil {
+			return nil, stall
+		}
+		return nil, err
+	}
+
+	if !t.upload {
+		w.start()
+	}
+	resp.Body = &watchedBody{ReadCloser: resp.Body, reader: &watchedReader{r: resp.Body, w: w}, w: w}
+	return resp, nil
+}
+
+// watchdog 一次传输的停滞检测
+type watchdog struct {
+	idle     time.Duration
+	minSpeed int64
+	window   time.Duration
+	cancel   context.CancelFunc
+
+	// pauseBetweenReads 两次 Read 之间暂停计时，停滞只按阻塞在 Read 中的时间判断
+	pauseBetweenReads bool
+
+	mu          sync.Mutex
+	last        time.Time // 最近一次有数据的时间
+	windowStart time.Time
+	windowBytes int64
+	paused      bool      // 当前不在 Read 中，不检查停滞
+	pausedAt    time.Time // 开始暂停的时间
+	err         error
+	done        chan struct{}
+	stopOnce    sync.Once
+}
+
+// start 开始计时并定期检查
+func (w *watchdog) start() {
+	now := time.Now()
+	w.mu.Lock()
+	w.last, w.windowStart = now, now
+	w.paused, w.pausedAt = w.pauseBetweenReads, now
+	w.done = make(chan struct{})
+	w.mu.Unlock()
+
+	if w.idle <= 0 && w.minSpeed <= 0 {
+		return
+	}
+	go w.run(w.done)
+}
+
+func (w *watchdog) run(done chan struct{}) {
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case now := <-ticker.C:
+			if w.check(now) {
+				return
+			}
+		}
+	}
+}
+
+// check 判断是否停滞，停滞时取消请求并返回 true
+func (w *watchdog) check(now time.Time) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.paused {
+		return false
+	}
+	switch {
+	case w.idle > 0 && now.Sub(w.last) >= w.idle:
+		w.err = &StallError{Reason: fmt.Sprintf("%s 内没有收到或发出数据", w.idle)}
+	case w.minSpeed > 0 && w.window > 0 && now.Sub(w.windowStart) >= w.window:
+		elapsed := now.Sub(w.windowStart)
+		speed := int64(float64(w.windowBytes) / elapsed.Seconds())
+		if speed < w.minSpeed {
+			w.err = &StallError{Reason: fmt.Sprintf("最近 %s 平均速度 %s，低于 %s",
+				elapsed.Round(time.Second), config.FormatRate(speed), config.FormatRate(w.minSpeed))}
+		} else {
+			w.windowStart, w.windowBytes = now, 0
+		}
+	}
+	if w.err == nil {
+		return false
+	}
+	w.cancel()
+	return true
+}
+
+// enterRead 开始一次 Read，恢复计时；暂停的时间不计入空闲时间和速度窗口
+func (w *watchdog) enterRead() {
+	if !w.pauseBetweenReads {
+		return
+	}
+	w.mu.Lock()
+	if w.paused {
+		paused := time.Since(w.pausedAt)
+		w.last = w.last.Add(paused)
+		w.windowStart = w.windowStart.Add(paused)
+		w.paused = false
+	}
+	w.mu.Unlock()
+}
+
+// exitRead 一次 Read 返回，记录传输的字节数；读取方处理数据期间暂停计时
+func (w *watchdog) exitRead(n int) {
+	w.mu.Lock()
+	now := time.Now()
+	if n > 0 {
+		w.last = now
+		w.windowBytes += int64(n)
+	}
+	if w.pauseBetweenReads {
+		w.paused, w.pausedAt = true, now
+	}
+	w.mu.Unlock()
+}
+
+// stop 停止检查，可以重复调用
+func (w *watchdog) stop() {
+	w.stopOnce.Do(func() {
+		w.mu.Lock()
+		done := w.done
+		w.mu.Unlock()
+		if done != nil {
+			close(done)
+		}
+	})
+}
+
+// Err 停滞时返回 StallError
+func (w *watchdog) Err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+// watchedReader 读取时记录进展，读到结尾后停止监控；停滞造成的读取错误替换为 StallError
+type watchedReader struct {
+	r io.Reader
+	w *watchdog
+}
+
+func (r *watchedReader) Read(p []byte) (int, error) {
+	r.w.enterRead()
+	n, err := r.r.Read(p)
+	r.w.exitRead(n)
+	if err == io.EOF {
+		r.w.stop()
+	} else if err != nil {
+		if stall := r.w.Err(); stall != nil {
+			err = stall
+		}
+	}
+	return n, err
+}
+
+// watchedBody 响应体，关闭时停止监控并释放请求的 context
+type watchedBody struct {
+	io.ReadCloser
+	reader *watchedReader
+	w      *watchdog
+}
+
+func (b *watchedBody) Read(p []byte) (int, error) {
+	return b.reader.Read(p)
+}
+
+func (b *watchedBody) Close() error {
+	b.w.stop()
+	err := b.ReadCloser.Close()
+	b.w.cancel()
+	return err
+}
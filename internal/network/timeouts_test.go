@@ -0,0 +1,50 @@
+package network
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWatchdogPausesBetweenReads(t *testing.T) {
+	tests := []struct {
+		name              string
+		pauseBetweenReads bool
+		reading           bool
+		wantStall         bool
+	}{
+		{name: "下载，读取方暂停", pauseBetweenReads: true, reading: false, wantStall: false},
+		{name: "下载，阻塞在 Read 中", pauseBetweenReads: true, reading: true, wantStall: true},
+		{name: "上传，两次读取之间", pauseBetweenReads: false, reading: false, wantStall: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &watchdog{idle: time.Minute, cancel: func() {}, pauseBetweenReads: tt.pauseBetweenReads}
+			w.start()
+			defer w.stop()
+			w.exitRead(0)
+			if tt.reading {
+				w.enterRead()
+			}
+
+			if got := w.check(time.Now().Add(2 * time.Minute)); got != tt.wantStall {
+				t.Fatalf("check() = %v, want %v", got, tt.wantStall)
+			}
+		})
+	}
+}
+
+func TestWatchdogExcludesPauseFromIdle(t *testing.T) {
+	w := &watchdog{idle: 50 * time.Millisecond, cancel: func() {}, pauseBetweenReads: true}
+	w.start()
+	defer w.stop()
+
+	// 暂停的时间远超 idle，恢复读取后重新开始计时
+	time.Sleep(100 * time.Millisecond)
+	w.enterRead()
+	if w.check(time.Now()) {
+		t.Fatal("暂停期间的时间不应计入空闲时间")
+	}
+	if !w.check(time.Now().Add(100 * time.Millisecond)) {
+		t.Fatal("阻塞在 Read 中超过 idle 应判为停滞")
+	}
+}
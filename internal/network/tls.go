@@ -0,0 +1,166 @@
+package network
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/difyz9/Link2COS/config"
+)
+
+// tlsVersions min_version 可选的值
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// NewTLSConfig 根据配置创建 tls.Config，没有任何设置时返回 nil（使用系统默认）
+// proxies 为同一 Transport 使用的代理：https 代理的握手也使用这里的配置，不填 host 的公钥固定不用于代理
+func NewTLSConfig(c config.TLSConfig, proxies []*url.URL) (*tls.Config, error) {
+	if c.IsZero() {
+		return nil, nil
+	}
+
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if c.MinVersion != "" {
+		version, ok := tlsVersions[c.MinVersion]
+		if !ok {
+			return nil, fmt.Errorf("无效的 TLS 版本: %s（可选: 1.0, 1.1, 1.2, 1.3）", c.MinVersion)
+		}
+		cfg.MinVersion = version
+	}
+
+	// 额外的 CA 与系统证书一起使用，例如企业代理做 TLS 拦截时的根证书
+	if len(c.CAFiles) > 0 {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		for _, path := range c.CAFiles {
+			pem, err := os.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("读取 CA 证书失败: %w", err)
+			}
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("CA 证书文件中没有有效的 PEM 证书: %s", path)
+			}
+		}
+		cfg.RootCAs = pool
+	}
+
+	if c.ClientCert != "" || c.ClientKey != "" {
+		cert, err := tls.LoadX509KeyPair(c.ClientCert, c.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("加载客户端证书失败: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	if len(c.Pins) > 0 {
+		pins, err := parsePins(c.Pins)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range proxies {
+			pins.proxyHosts = append(pins.proxyHosts, p.Hostname())
+		}
+		cfg.VerifyConnection = pins.verify
+	}
+
+	return cfg, nil
+}
+
+// pinSet 按主机的公钥固定
+type pinSet struct {
+	rules      []hostPins
+	proxyHosts []string // 代理的主机，不填 host 的规则只用于目标服务器，不用于与 https 代理的握手
+}
+
+type hostPins struct {
+	host   string
+	hashes map[string]bool // Base64 编码的 SPKI SHA-256
+}
+
+func parsePins(pins []config.TLSPin) (*pinSet, error) {
+	set := &pinSet{}
+	for _, p := range pins {
+		hp := hostPins{host: p.Host, hashes: make(map[string]bool)}
+		for _, hash := range p.SHA256 {
+			hash = strings.TrimPrefix(hash, "sha256/")
+			if sum, err := base64.StdEncoding.DecodeString(hash); err != nil || len(sum) != sha256.Size {
+				return nil, fmt.Errorf("无效的公钥固定值: %s", hash)
+			}
+			hp.hashes[hash] = true
+		}
+		set.rules = append(set.rules, hp)
+	}
+	return set, nil
+}
+
+// verify 在常规证书校验之后执行：第一条匹配主机的规则要求证书链中至少一个证书的公钥在列表中
+func (s *pinSet) verify(cs tls.ConnectionState) error {
+	proxy := s.isProxy(cs)
+	for _, hp := range s.rules {
+		if hp.host == "" && proxy {
+			continue
+		}
+		if hp.host != "" && !matchServer(hp.host, cs) {
+			continue
+		}
+
+		chains := cs.VerifiedChains
+		if len(chains) == 0 {
+			chains = [][]*x509.Certificate{cs.PeerCertificates}
+		}
+		for _, chain := range chains {
+			for _, cert := range chain {
+				if hp.hashes[spkiHash(cert)] {
+					return nil
+				}
+			}
+		}
+		return errors.New("证书公钥与配置的 pins 不匹配")
+	}
+	return nil
+}
+
+// isProxy 握手的对端是否为配置的代理
+func (s *pinSet) isProxy(cs tls.ConnectionState) bool {
+	for _, host := range s.proxyHosts {
+		if matchServer(host, cs) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchServer 匹配连接的主机；访问 IP 时不发送 SNI，ServerName 为空，此时用已通过校验的证书中的 IP 匹配
+func matchServer(pattern string, cs tls.ConnectionState) bool {
+	if cs.ServerName != "" {
+		return MatchHost(pattern, cs.ServerName)
+	}
+	if len(cs.PeerCertificates) == 0 {
+		return false
+	}
+	for _, ip := range cs.PeerCertificates[0].IPAddresses {
+		if MatchHost(pattern, ip.String()) {
+			return true
+		}
+	}
+	return false
+}
+
+// spkiHash 返回证书公钥（SubjectPublicKeyInfo）的 SHA-256，Base64 编码
+func spkiHash(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
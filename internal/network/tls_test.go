@@ -0,0 +1,49 @@
+package network
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/url"
+	"testing"
+
+	"github.com/difyz9/Link2COS/config"
+)
+
+func TestPinSetVerify(t *testing.T) {
+	pinned := &x509.Certificate{RawSubjectPublicKeyInfo: []byte("pinned")}
+	other := &x509.Certificate{RawSubjectPublicKeyInfo: []byte("other")}
+	proxyIP := &x509.Certificate{RawSubjectPublicKeyInfo: []byte("proxy"), IPAddresses: []net.IP{net.ParseIP("10.0.0.1")}}
+
+	state := func(server string, cert *x509.Certificate) tls.ConnectionState {
+		return tls.ConnectionState{ServerName: server, PeerCertificates: []*x509.Certificate{cert}}
+	}
+	proxies := []*url.URL{{Scheme: "https", Host: "proxy.corp:3128"}, {Scheme: "https", Host: "10.0.0.1:3128"}}
+
+	tests := []struct {
+		name    string
+		pins    []config.TLSPin
+		cs      tls.ConnectionState
+		wantErr bool
+	}{
+		{"不填 host 的规则匹配目标服务器", []config.TLSPin{{SHA256: []string{spkiHash(pinned)}}}, state("cos.example.com", pinned), false},
+		{"不填 host 的规则拒绝目标服务器的其他证书", []config.TLSPin{{SHA256: []string{spkiHash(pinned)}}}, state("cos.example.com", other), true},
+		{"不填 host 的规则不用于代理", []config.TLSPin{{SHA256: []string{spkiHash(pinned)}}}, state("proxy.corp", other), false},
+		{"不填 host 的规则不用于 IP 代理", []config.TLSPin{{SHA256: []string{spkiHash(pinned)}}}, state("", proxyIP), false},
+		{"代理主机的规则用于代理", []config.TLSPin{{Host: "proxy.corp", SHA256: []string{spkiHash(pinned)}}}, state("proxy.corp", other), true},
+		{"其他主机不做固定", []config.TLSPin{{Host: "*.example.com", SHA256: []string{spkiHash(pinned)}}}, state("files.example.org", other), false},
+		{"带 sha256/ 前缀", []config.TLSPin{{Host: "*.example.com", SHA256: []string{"sha256/" + spkiHash(pinned)}}}, state("cos.example.com", pinned), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := NewTLSConfig(config.TLSConfig{Pins: tt.pins}, proxies)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = cfg.VerifyConnection(tt.cs)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("校验结果 %v，应%s", err, map[bool]string{true: "失败", false: "通过"}[tt.wantErr])
+			}
+		})
+	}
+}
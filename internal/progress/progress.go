@@ -0,0 +1,381 @@
+package progress
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/difyz9/Link2COS/internal/console"
+	"github.com/difyz9/Link2COS/internal/events"
+	"github.com/difyz9/Link2COS/internal/util"
+)
+
+// Stage 文件需要经过的传输阶段，sync 的文件先下载再上传
+type Stage int
+
+const (
+	Download Stage = 1 << iota
+	Upload
+)
+
+// 刷新间隔：终端上实时刷新进度条，非终端时定期输出一行文字，JSON 输出模式下每秒输出进度事件
+const (
+	ttyInterval   = 200 * time.Millisecond
+	plainInterval = 10 * time.Second
+	eventInterval = time.Second
+)
+
+// Progress 一次运行的进度：正在传输的文件和整体的链接数、字节数
+// 字节数在下载的 Reader 和上传的分块中统计，可以从多个协程并发更新
+type Progress struct {
+	mu         sync.Mutex
+	totalLinks int
+	doneLinks  int
+	files      []*File
+	finished   []int64 // 已完成文件的工作量，用于估算剩余链接
+
+	transferred atomic.Int64 // 所有文件已传输的字节数（下载和上传分别计入）
+	start       time.Time
+	tty         bool
+	json        bool
+	stop        chan struct{}
+	stopOnce    sync.Once
+	wg          sync.WaitGroup
+}
+
+// New 创建进度并开始刷新，totalLinks 为本次要处理的链接数
+func New(totalLinks int) *Progress {
+	p := &Progress{
+		totalLinks: totalLinks,
+		start:      time.Now(),
+		json:       events.Enabled(),
+		stop:       make(chan struct{}),
+	}
+	// JSON 输出模式下不绘制进度条
+	p.tty = !p.json && console.StatusSupported()
+
+	interval := plainInterval
+	switch {
+	case p.json:
+		interval = eventInterval
+	case p.tty:
+		interval = ttyInterval
+	}
+	p.wg.Add(1)
+	go p.run(interval)
+	return p
+}
+
+// Stop 停止刷新并擦除进度条，可以对 nil 调用，也可以重复调用
+func (p *Progress) Stop() {
+	if p == nil {
+		return
+	}
+	p.stopOnce.Do(func() {
+		close(p.stop)
+		p.wg.Wait()
+		if p.tty {
+			console.ClearStatus()
+		}
+	})
+}
+
+// LinkDone 一个链接处理结束（成功、失败或跳过）
+func (p *Progress) LinkDone() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	p.doneLinks++
+	p.mu.Unlock()
+}
+
+// Start 开始传输一个文件，link 为文件的来源（链接或本地路径），size < 0 表示大小未知
+func (p *Progress) Start(link, name string, size int64, stages Stage) *File {
+	if p == nil {
+		return nil
+	}
+	f := &File{p: p, link: link, name: name, stages: stages, start: time.Now()}
+	f.size.Store(size)
+
+	p.mu.Lock()
+	p.files = append(p.files, f)
+	p.mu.Unlock()
+	return f
+}
+
+func (p *Progress) run(interval time.Duration) {
+	defer p.wg.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			p.render()
+		}
+	}
+}
+
+// render 终端上重绘进度条；非终端时有文件在传输才输出
+func (p *Progress) render() {
+	p.mu.Lock()
+	files := append([]*File(nil), p.files...)
+	overall := p.overallLocked()
+	p.mu.Unlock()
+
+	if p.json {
+		for _, f := range files {
+			f.emit()
+		}
+		return
+	}
+
+	if p.tty {
+		lines := make([]string, 0, len(files)+1)
+		for _, f := range files {
+			lines = append(lines, f.line(true))
+		}
+		lines = append(lines, overall)
+		console.SetStatus(lines)
+		return
+	}
+
+	if len(files) == 0 {
+		return
+	}
+	for _, f := range files {
+		console.Printf("  进度: %s\n", f.line(false))
+	}
+	console.Printf("  %s\n", overall)
+}
+
+// overallLocked 整体进度：链接数、已传输字节、平均速度和预计剩余时间，调用方需持有锁
+func (p *Progress) overallLocked() string {
+	elapsed := time.Since(p.start).Seconds()
+	transferred := p.transferred.Load()
+	speed := 0.0
+	if elapsed > 0 {
+		speed = float64(transferred) / elapsed
+	}
+
+	// 剩余工作量 = 正在传输文件的剩余部分 + 未开始链接数 × 已完成文件的平均工作量
+	remaining, known := int64(0), true
+	for _, f := range p.files {
+		total, done := f.work()
+		if total < 0 {
+			known = false
+			continue
+		}
+		remaining += total - done
+	}
+	pending := p.totalLinks - p.doneLinks - len(p.files)
+	if pending > 0 {
+		if len(p.finished) == 0 {
+			known = false
+		} else {
+			var sum int64
+			for _, w := range p.finished {
+				sum += w
+			}
+			remaining += sum / int64(len(p.finished)) * int64(pending)
+		}
+	}
+
+	eta := "--:--"
+	if known && speed > 0 {
+		eta = formatDuration(time.Duration(float64(remaining) / speed * float64(time.Second)))
+	}
+
+	return fmt.Sprintf("总体 %s %d/%d 个链接  已传输 %s  %s/s  剩余 %s",
+		bar(float64(p.doneLinks)/float64(max(p.totalLinks, 1))), p.doneLinks, p.totalLinks,
+		util.FormatBytes(transferred), util.FormatBytes(int64(speed)), eta)
+}
+
+// File 单个文件的进度
+type File struct {
+	p      *Progress
+	link   string
+	name   string
+	stages Stage
+	start  time.Time
+
+	size       atomic.Int64
+	downloaded atomic.Int64
+	uploaded   atomic.Int64
+	closed     atomic.Bool
+}
+
+// SetSize 设置文件大小，例如大小未知的数据流读完之后
+func (f *File) SetSize(size int64) {
+	if f != nil {
+		f.size.Store(size)
+	}
+}
+
+// AddDownloaded 记录下载的字节数
+func (f *File) AddDownloaded(n int64) {
+	if f != nil {
+		f.downloaded.Add(n)
+		f.p.transferred.Add(n)
+	}
+}
+
+// AddUploaded 记录上传的字节数，上传失败重试时可以传入负数撤销
+func (f *File) AddUploaded(n int64) {
+	if f != nil {
+		f.uploaded.Add(n)
+		f.p.transferred.Add(n)
+	}
+}
+
+// DownloadReader 返回统计下载字节数的 Reader
+func (f *File) DownloadReader(r io.Reader) io.Reader {
+	if f == nil {
+		return r
+	}
+	return &countingReader{r: r, add: f.AddDownloaded}
+}
+
+// Close 文件传输结束（成功或失败），从进度中移除；可以重复调用
+func (f *File) Close() {
+	if f == nil || !f.closed.CompareAndSwap(false, true) {
+		return
+	}
+
+	p := f.p
+	p.mu.Lock()
+	for i, other := range p.files {
+		if other == f {
+			p.files = append(p.files[:i], p.files[i+1:]...)
+			break
+		}
+	}
+	// 只有完整传输的文件用于估算剩余链接，失败重试的部分不计入
+	if total, done := f.work(); total > 0 && done >= total {
+		p.finished = append(p.finished, total)
+	}
+	p.mu.Unlock()
+
+	// 终端上立即移除该文件的进度条
+	if p.tty {
+		p.render()
+	}
+}
+
+// work 返回总工作量和已完成的工作量（每个阶段各计一次文件大小），大小未知时总量为 -1
+func (f *File) work() (int64, int64) {
+	done := int64(0)
+	stages := int64(0)
+	if f.stages&Download != 0 {
+		done += f.downloaded.Load()
+		stages++
+	}
+	if f.stages&Upload != 0 {
+		done += f.uploaded.Load()
+		stages++
+	}
+	size := f.size.Load()
+	if size < 0 {
+		return -1, done
+	}
+	return size * stages, done
+}
+
+// line 单个文件的进度描述
+func (f *File) line(withBar bool) string {
+	total, done := f.work()
+	elapsed := time.Since(f.start).Seconds()
+
+	var b strings.Builder
+	b.WriteString(f.name)
+	if total > 0 {
+		fraction := float64(done) / float64(total)
+		if withBar {
+			b.WriteString(" " + bar(fraction))
+		}
+		fmt.Fprintf(&b, " %5.1f%% %s", fraction*100, util.FormatBytes(f.size.Load()))
+	} else if total < 0 {
+		b.WriteString(" 大小未知")
+	}
+
+	if f.stages&Download != 0 {
+		fmt.Fprintf(&b, "  ↓ %s", util.FormatBytes(f.downloaded.Load()))
+		if elapsed > 0 {
+			fmt.Fprintf(&b, " %s/s", util.FormatBytes(int64(float64(f.downloaded.Load())/elapsed)))
+		}
+	}
+	if f.stages&Upload != 0 {
+		fmt.Fprintf(&b, "  ↑ %s", util.FormatBytes(f.uploaded.Load()))
+		if elapsed > 0 {
+			fmt.Fprintf(&b, " %s/s", util.FormatBytes(int64(float64(f.uploaded.Load())/elapsed)))
+		}
+	}
+
+	if total > 0 && done > 0 && elapsed > 0 {
+		rate := float64(done) / elapsed
+		fmt.Fprintf(&b, "  剩余 %s", formatDuration(time.Duration(float64(total-done)/rate*float64(time.Second))))
+	}
+	return b.String()
+}
+
+// emit 输出该文件的进度事件
+func (f *File) emit() {
+	fields := events.Fields{
+		"link": f.link,
+		"name": f.name,
+		"size": events.Size(f.size.Load()),
+	}
+	if f.stages&Download != 0 {
+		fields["downloaded"] = f.downloaded.Load()
+	}
+	if f.stages&Upload != 0 {
+		fields["uploaded"] = f.uploaded.Load()
+	}
+	if elapsed := time.Since(f.start).Seconds(); elapsed > 0 {
+		_, done := f.work()
+		fields["bytes_per_sec"] = int64(float64(done) / elapsed)
+	}
+	events.Emit("progress", fields)
+}
+
+// countingReader 每次读取后回调读取的字节数
+type countingReader struct {
+	r   io.Reader
+	add func(int64)
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	if n > 0 {
+		c.add(int64(n))
+	}
+	return n, err
+}
+
+// bar 绘制固定宽度的进度条
+func bar(fraction float64) string {
+	const width = 20
+	if fraction < 0 {
+		fraction = 0
+	}
+	if fraction > 1 {
+		fraction = 1
+	}
+	filled := int(fraction * width)
+	return "[" + strings.Repeat("=", filled) + strings.Repeat(" ", width-filled) + "]"
+}
+
+// formatDuration 将时长格式化为 mm:ss 或 h:mm:ss
+func formatDuration(d time.Duration) string {
+	s := int64(d.Round(time.Second).Seconds())
+	if s >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", s/3600, s/60%60, s%60)
+	}
+	return fmt.Sprintf("%02d:%02d", s/60, s%60)
+}
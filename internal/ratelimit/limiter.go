@@ -0,0 +1,147 @@
+package ratelimit
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// minBurst 令牌桶的最小容量，速率很低时也允许一次读写一个常见大小的缓冲区
+const minBurst = 32 * 1024
+
+// Limiter 令牌桶限速器，可在多个协程间共享，速率可在运行中调整
+type Limiter struct {
+	mu     sync.Mutex
+	rate   float64 // 每秒字节数，0 表示不限速
+	burst  float64
+	tokens float64
+	last   time.Time
+	// changed 在速率调整时关闭并替换，唤醒正在等待的调用按新速率重新计算
+	changed chan struct{}
+}
+
+// NewLimiter 创建限速器，rate 为每秒字节数，0 表示不限速
+func NewLimiter(rate int64) *Limiter {
+	l := &Limiter{}
+	l.SetRate(rate)
+	return l
+}
+
+// SetRate 调整速率，正在等待的读写按新速率继续
+func (l *Limiter) SetRate(rate int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.changed != nil {
+		close(l.changed)
+	}
+	l.changed = make(chan struct{})
+
+	now := time.Now()
+	first := l.last.IsZero()
+	l.refill(now)
+
+	l.rate = float64(rate)
+	l.burst = l.rate
+	if l.burst < minBurst {
+		l.burst = minBurst
+	}
+	if first || l.rate <= 0 || l.tokens > l.burst {
+		// 新建或不限速时桶是满的，之后限速从满桶开始
+		l.tokens = l.burst
+	}
+	l.last = now
+}
+
+// Rate 返回当前速率，0 表示不限速
+func (l *Limiter) Rate() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return int64(l.rate)
+}
+
+// refill 按经过的时间补充令牌，调用方需持有锁
+func (l *Limiter) refill(now time.Time) {
+	if l.rate > 0 && !l.last.IsZero() {
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+	}
+	l.last = now
+}
+
+// WaitN 消耗 n 个字节的令牌，令牌不足时等待；n 可以超过桶容量，超出部分记为欠账由之后的调用偿还。
+// 等待期间速率被调整时，尚欠的令牌按新速率重新计算等待时间，调整为不限速时立即返回
+func (l *Limiter) WaitN(ctx context.Context, n int) error {
+	l.mu.Lock()
+	if l.rate <= 0 {
+		l.mu.Unlock()
+		return nil
+	}
+	l.refill(time.Now())
+	l.tokens -= float64(n)
+	// owed 为本次调用放行前桶中还需补充的令牌数，包含排在前面的欠账
+	owed := -l.tokens
+	rate := l.rate
+	changed := l.changed
+	l.mu.Unlock()
+
+	for owed > 0 {
+		start := time.Now()
+		timer := time.NewTimer(time.Duration(owed / rate * float64(time.Second)))
+		select {
+		case <-timer.C:
+			return nil
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-changed:
+			timer.Stop()
+		}
+
+		owed -= time.Since(start).Seconds() * rate
+		l.mu.Lock()
+		rate = l.rate
+		changed = l.changed
+		l.mu.Unlock()
+		if rate <= 0 {
+			return nil
+		}
+	}
+	return nil
+}
+
+// readChunk 每次读取的最大字节数，使限速更平滑
+const readChunk = 32 * 1024
+
+// reader 读取后按字节数依次等待每个限速器
+type reader struct {
+	ctx      context.Context
+	r        io.Reader
+	limiters []*Limiter
+}
+
+// NewReader 返回受限速器约束的 Reader，limiters 为空时直接返回 r
+func NewReader(ctx context.Context, r io.Reader, limiters ...*Limiter) io.Reader {
+	if len(limiters) == 0 {
+		return r
+	}
+	return &reader{ctx: ctx, r: r, limiters: limiters}
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	if len(p) > readChunk {
+		p = p[:readChunk]
+	}
+	n, err := r.r.Read(p)
+	if n > 0 {
+		for _, l := range r.limiters {
+			if werr := l.WaitN(r.ctx, n); werr != nil {
+				return n, werr
+			}
+		}
+	}
+	return n, err
+}
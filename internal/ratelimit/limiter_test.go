@@ -0,0 +1,86 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWaitNAdoptsNewRate(t *testing.T) {
+	tests := []struct {
+		name    string
+		newRate int64
+		maxWait time.Duration
+	}{
+		{"调整为不限速", 0, time.Second},
+		{"调高速率", 1 << 30, time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 1000 字节/秒，先耗尽桶，再等待 10000 字节约需 10 秒
+			l := NewLimiter(1000)
+			if err := l.WaitN(context.Background(), minBurst); err != nil {
+				t.Fatalf("耗尽令牌失败: %v", err)
+			}
+
+			done := make(chan error, 1)
+			start := time.Now()
+			go func() { done <- l.WaitN(context.Background(), 10000) }()
+
+			time.Sleep(50 * time.Millisecond)
+			l.SetRate(tt.newRate)
+
+			select {
+			case err := <-done:
+				if err != nil {
+					t.Fatalf("WaitN 返回错误: %v", err)
+				}
+				if elapsed := time.Since(start); elapsed > tt.maxWait {
+					t.Errorf("等待 %v，应在 %v 内按新速率返回", elapsed, tt.maxWait)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("调整速率后等待中的 WaitN 未被唤醒")
+			}
+		})
+	}
+}
+
+func TestWaitNSlowerRateExtendsWait(t *testing.T) {
+	l := NewLimiter(1 << 20)
+	if err := l.WaitN(context.Background(), 1<<20); err != nil {
+		t.Fatalf("耗尽令牌失败: %v", err)
+	}
+
+	// 欠 100KB，按 1MB/秒约 0.1 秒；调低到 1000 字节/秒后应远超这一时间
+	done := make(chan error, 1)
+	go func() { done <- l.WaitN(context.Background(), 100<<10) }()
+	time.Sleep(10 * time.Millisecond)
+	l.SetRate(1000)
+
+	select {
+	case <-done:
+		t.Fatal("调低速率后 WaitN 仍按原速率返回")
+	case <-time.After(500 * time.Millisecond):
+	}
+	l.SetRate(0)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("WaitN 返回错误: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("调整为不限速后 WaitN 未返回")
+	}
+}
+
+func TestWaitNContextCancel(t *testing.T) {
+	l := NewLimiter(1000)
+	if err := l.WaitN(context.Background(), minBurst); err != nil {
+		t.Fatalf("耗尽令牌失败: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := l.WaitN(ctx, 10000); err != context.DeadlineExceeded {
+		t.Errorf("WaitN = %v，应为 %v", err, context.DeadlineExceeded)
+	}
+}
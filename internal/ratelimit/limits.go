@@ -0,0 +1,148 @@
+package ratelimit
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/difyz9/Link2COS/config"
+	"github.com/difyz9/Link2COS/internal/network"
+)
+
+// Limits 一次运行中共享的一组限速器：合计、下载、上传和按主机
+// 所有链接、所有分块上传协程使用同一个 Limits，限速对整体生效
+type Limits struct {
+	Total    *Limiter
+	Download *Limiter
+	Upload   *Limiter
+	hosts    []hostLimiter
+
+	cfg  config.BandwidthConfig
+	stop chan struct{}
+}
+
+type hostLimiter struct {
+	pattern string
+	limiter *Limiter
+}
+
+// NewLimits 根据 bandwidth 配置创建限速器，未配置的项不限速；没有任何限速配置时返回 nil
+func NewLimits(cfg config.BandwidthConfig) (*Limits, error) {
+	if cfg.IsZero() {
+		return nil, nil
+	}
+
+	parse := func(field, value string) (*Limiter, error) {
+		rate, err := config.ParseRate(value)
+		if err != nil {
+			return nil, fmt.Errorf("bandwidth.%s: %w", field, err)
+		}
+		return NewLimiter(rate), nil
+	}
+
+	l := &Limits{cfg: cfg}
+	var err error
+	if l.Total, err = parse("total", cfg.BaseTotal()); err != nil {
+		return nil, err
+	}
+	if l.Download, err = parse("download", cfg.Download); err != nil {
+		return nil, err
+	}
+	if l.Upload, err = parse("upload", cfg.Upload); err != nil {
+		return nil, err
+	}
+	for i, h := range cfg.Hosts {
+		limiter, err := parse(fmt.Sprintf("hosts[%d].rate", i), h.Rate)
+		if err != nil {
+			return nil, err
+		}
+		l.hosts = append(l.hosts, hostLimiter{pattern: h.Host, limiter: limiter})
+	}
+
+	// 按时段调整速率
+	if len(cfg.Schedule) > 0 {
+		if err := l.applySchedule(time.Now()); err != nil {
+			return nil, err
+		}
+		l.stop = make(chan struct{})
+		go l.runSchedule(l.stop)
+	}
+	return l, nil
+}
+
+// Stop 停止按时段调整速率，可以对 nil 调用
+func (l *Limits) Stop() {
+	if l != nil && l.stop != nil {
+		close(l.stop)
+		l.stop = nil
+	}
+}
+
+// forRequest 返回一次传输需要经过的限速器
+// 暂时不限速的限速器也包含在内，运行中调整速率后对正在进行的传输立即生效
+func (l *Limits) forRequest(direction *Limiter, host string) []*Limiter {
+	list := []*Limiter{l.Total, direction}
+	for _, h := range l.hosts {
+		if network.MatchHost(h.pattern, host) {
+			list = append(list, h.limiter)
+			break
+		}
+	}
+	return list
+}
+
+// WrapDownload 限制响应体的读取速度（用于下载源）
+func (l *Limits) WrapDownload(base http.RoundTripper) http.RoundTripper {
+	if l == nil {
+		return base
+	}
+	return &transport{base: base, limits: l}
+}
+
+// WrapUpload 限制请求体的发送速度（用于上传到COS）
+func (l *Limits) WrapUpload(base http.RoundTripper) http.RoundTripper {
+	if l == nil {
+		return base
+	}
+	return &transport{base: base, limits: l, upload: true}
+}
+
+// transport 在请求体或响应体上套用限速器
+type transport struct {
+	base   http.RoundTripper
+	limits *Limits
+	upload bool
+}
+
+// RoundTrip implements the RoundTripper interface.
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	if t.upload {
+		if req.Body != nil && req.Body != http.NoBody {
+			limiters := t.limits.forRequest(t.limits.Upload, req.URL.Hostname())
+			body := req.Body
+			// 保留 ContentLength，只替换 Body
+			req = req.Clone(req.Context())
+			req.Body = readCloser{NewReader(req.Context(), body, limiters...), body}
+		}
+		return base.RoundTrip(req)
+	}
+
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	limiters := t.limits.forRequest(t.limits.Download, req.URL.Hostname())
+	resp.Body = readCloser{NewReader(req.Context(), resp.Body, limiters...), resp.Body}
+	return resp, nil
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,63 @@
+package ratelimit
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/difyz9/Link2COS/config"
+	"github.com/difyz9/Link2COS/internal/console"
+)
+
+// scheduleInterval 检查时段的间隔，时段精确到分钟
+const scheduleInterval = 15 * time.Second
+
+// runSchedule 定期按当前时间调整速率，直到 Stop 被调用
+// 限速器在所有传输之间共享，调整后正在进行的下载和上传立即按新速率继续
+func (l *Limits) runSchedule(stop <-chan struct{}) {
+	ticker := time.NewTicker(scheduleInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			if err := l.applySchedule(now); err != nil {
+				console.Printf("  警告: 调整带宽限制失败: %v\n", err)
+			}
+		}
+	}
+}
+
+// applySchedule 将时间 now 对应的速率应用到限速器，速率有变化时输出提示
+func (l *Limits) applySchedule(now time.Time) error {
+	total, download, upload := l.cfg.RatesAt(now)
+
+	changed := false
+	set := func(limiter *Limiter, field, value string) error {
+		rate, err := config.ParseRate(value)
+		if err != nil {
+			return fmt.Errorf("bandwidth.%s: %w", field, err)
+		}
+		if limiter.Rate() != rate {
+			limiter.SetRate(rate)
+			changed = true
+		}
+		return nil
+	}
+	if err := set(l.Total, "total", total); err != nil {
+		return err
+	}
+	if err := set(l.Download, "download", download); err != nil {
+		return err
+	}
+	if err := set(l.Upload, "upload", upload); err != nil {
+		return err
+	}
+
+	if changed {
+		console.Printf("  [%s] 带宽限制: 总计 %s, 下载 %s, 上传 %s\n", now.Format("15:04"),
+			config.FormatRate(l.Total.Rate()), config.FormatRate(l.Download.Rate()), config.FormatRate(l.Upload.Rate()))
+	}
+	return nil
+}
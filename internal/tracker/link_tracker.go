@@ -4,13 +4,23 @@ import (
 	"bufio"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
 	"sync"
 )
 
+// Entry 已下载链接的记录
+// 记录文件每行一条，字段以制表符分隔: 链接[\t保存位置[\t字节数]]，旧版本只有链接一列
+type Entry struct {
+	Link        string
+	Destination string // 本地保存路径或COS路径
+	Size        int64  // 校验通过的文件大小，-1 表示未知
+}
+
 // LinkTracker 用于跟踪已下载的链接
 type LinkTracker struct {
 	filePath string
-	links    map[string]bool
+	links    map[string]Entry
 	mu       sync.RWMutex
 }
 
@@ -18,7 +28,7 @@ type LinkTracker struct {
 func NewLinkTracker(filePath string) (*LinkTracker, error) {
 	tracker := &LinkTracker{
 		filePath: filePath,
-		links:    make(map[string]bool),
+		links:    make(map[string]Entry),
 	}
 
 	// 尝试从文件加载已有的链接记录
@@ -42,10 +52,23 @@ func (lt *LinkTracker) load() error {
 
 	scanner := bufio.NewScanner(file)
 	for scanner.Scan() {
-		link := scanner.Text()
-		if link != "" {
-			lt.links[link] = true
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		entry := Entry{Link: fields[0], Size: -1}
+		if len(fields) > 1 {
+			entry.Destination = fields[1]
+		}
+		if len(fields) > 2 {
+			if size, err := strconv.ParseInt(fields[2], 10, 64); err == nil {
+				entry.Size = size
+			}
 		}
+		// 同一链接出现多次时以最后一条为准
+		lt.links[entry.Link] = entry
 	}
 
 	return scanner.Err()
@@ -55,21 +78,30 @@ func (lt *LinkTracker) load() error {
 func (lt *LinkTracker) IsDownloaded(link string) bool {
 	lt.mu.RLock()
 	defer lt.mu.RUnlock()
-	return lt.links[link]
+	_, ok := lt.links[link]
+	return ok
 }
 
-// MarkDownloaded 标记链接已下载
-func (lt *LinkTracker) MarkDownloaded(link string) error {
+// Get 获取链接的下载记录
+func (lt *LinkTracker) Get(link string) (Entry, bool) {
+	lt.mu.RLock()
+	defer lt.mu.RUnlock()
+	entry, ok := lt.links[link]
+	return entry, ok
+}
+
+// MarkDownloaded 标记链接已下载，并记录保存位置
+func (lt *LinkTracker) MarkDownloaded(entry Entry) error {
 	lt.mu.Lock()
 	defer lt.mu.Unlock()
 
-	// 如果已经存在，不需要重复添加
-	if lt.links[link] {
+	// 如果已经存在相同记录，不需要重复添加
+	if existing, ok := lt.links[entry.Link]; ok && existing == entry {
 		return nil
 	}
 
 	// 添加到内存中
-	lt.links[link] = true
+	lt.links[entry.Link] = entry
 
 	// 追加到文件
 	file, err := os.OpenFile(lt.filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
@@ -78,7 +110,14 @@ func (lt *LinkTracker) MarkDownloaded(link string) error {
 	}
 	defer file.Close()
 
-	if _, err := fmt.Fprintln(file, link); err != nil {
+	line := entry.Link
+	if entry.Destination != "" || entry.Size >= 0 {
+		line += "\t" + entry.Destination
+	}
+	if entry.Size >= 0 {
+		line += "\t" + strconv.FormatInt(entry.Size, 10)
+	}
+	if _, err := fmt.Fprintln(file, line); err != nil {
 		return fmt.Errorf("写入链接记录失败: %w", err)
 	}
 
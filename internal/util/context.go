@@ -0,0 +1,18 @@
+package util
+
+import (
+	"context"
+	"time"
+)
+
+// Sleep 等待 d，ctx 取消时提前返回 ctx 的错误
+func Sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,15 @@
+package util
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RelativeLinkPath 移除URL前缀，得到链接的相对路径（sync 计算COS路径使用同样的规则）
+func RelativeLinkPath(prefix, link string) (string, error) {
+	if prefix == "" || !strings.HasPrefix(link, prefix) {
+		return "", fmt.Errorf("链接不匹配配置的前缀")
+	}
+
+	return strings.TrimPrefix(link, prefix), nil
+}
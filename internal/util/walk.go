@@ -0,0 +1,214 @@
+package util
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// SymlinkPolicy 遍历目录时符号链接的处理方式
+type SymlinkPolicy string
+
+const (
+	// SymlinkSkip 跳过符号链接（默认）
+	SymlinkSkip SymlinkPolicy = "skip"
+	// SymlinkFollow 跟随符号链接，按链接所在的路径上传指向的文件或目录
+	SymlinkFollow SymlinkPolicy = "follow"
+	// SymlinkError 遇到符号链接时报错
+	SymlinkError SymlinkPolicy = "error"
+)
+
+// ParseSymlinkPolicy 解析符号链接策略，空字符串表示默认的 skip
+func ParseSymlinkPolicy(s string) (SymlinkPolicy, error) {
+	switch SymlinkPolicy(strings.ToLower(strings.TrimSpace(s))) {
+	case "", SymlinkSkip:
+		return SymlinkSkip, nil
+	case SymlinkFollow:
+		return SymlinkFollow, nil
+	case SymlinkError:
+		return SymlinkError, nil
+	}
+	return "", fmt.Errorf("未知的符号链接策略: %s（可选: skip, follow, error）", s)
+}
+
+// WalkOptions 遍历目录的过滤条件
+type WalkOptions struct {
+	Include  []string // 只包含匹配的文件，为空表示全部
+	Exclude  []string // 排除匹配的文件和目录，优先于 Include
+	Symlinks SymlinkPolicy
+}
+
+// LocalFile 遍历得到的本地文件
+type LocalFile struct {
+	Path string // 本地路径
+	Rel  string // 相对遍历根目录的路径，以 / 分隔
+	Size int64
+	Info os.FileInfo // 文件信息，符号链接为指向的文件
+}
+
+// WalkResult 遍历结果
+type WalkResult struct {
+	Files    []LocalFile
+	Symlinks []string // 按 skip 策略跳过的符号链接（相对路径）
+	Excluded int      // 被 include/exclude 过滤掉的文件数（被排除的目录不展开计数）
+}
+
+// CheckGlobs 检查通配符的语法
+func CheckGlobs(patterns []string) error {
+	for _, p := range patterns {
+		if _, err := path.Match(p, ""); err != nil {
+			return fmt.Errorf("无效的通配符: %s", p)
+		}
+	}
+	return nil
+}
+
+// MatchGlob 判断相对路径是否匹配通配符
+// 不含 / 的通配符匹配任意一级的名称，例如 *.tmp；含 / 的通配符匹配整个相对路径，** 匹配任意多级目录
+func MatchGlob(pattern, rel string) bool {
+	pattern = strings.Trim(pattern, "/")
+	if !strings.Contains(pattern, "/") {
+		for _, name := range strings.Split(rel, "/") {
+			if ok, _ := path.Match(pattern, name); ok {
+				return true
+			}
+		}
+		return false
+	}
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(rel, "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// WalkFiles 递归列出目录下的普通文件，按路径排序
+func WalkFiles(root string, opts WalkOptions) (*WalkResult, error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("不是目录: %s", root)
+	}
+
+	w := &walker{opts: opts, result: &WalkResult{}, visited: make(map[string]bool)}
+	if err := w.walk(root, ""); err != nil {
+		return nil, err
+	}
+	return w.result, nil
+}
+
+type walker struct {
+	opts    WalkOptions
+	result  *WalkResult
+	visited map[string]bool // 当前路径上各级目录的真实路径，跟随符号链接时避免循环
+}
+
+func (w *walker) walk(dir, rel string) error {
+	realDir, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return err
+	}
+	if w.visited[realDir] {
+		return nil
+	}
+	w.visited[realDir] = true
+	defer delete(w.visited, realDir)
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		localPath := filepath.Join(dir, entry.Name())
+		entryRel := path.Join(rel, entry.Name())
+
+		if w.excluded(entryRel) {
+			if !entry.IsDir() {
+				w.result.Excluded++
+			}
+			continue
+		}
+
+		mode := entry.Type()
+		if mode&os.ModeSymlink != 0 {
+			switch w.opts.Symlinks {
+			case SymlinkFollow:
+			case SymlinkError:
+				return fmt.Errorf("遇到符号链接: %s（可使用 --symlinks skip 或 follow）", localPath)
+			default:
+				w.result.Symlinks = append(w.result.Symlinks, entryRel)
+				continue
+			}
+			target, err := os.Stat(localPath)
+			if err != nil {
+				return fmt.Errorf("符号链接无效: %s: %w", localPath, err)
+			}
+			mode = target.Mode().Type()
+		}
+
+		switch {
+		case mode.IsDir():
+			if err := w.walk(localPath, entryRel); err != nil {
+				return err
+			}
+		case mode.IsRegular():
+			if len(w.opts.Include) > 0 && !matchAny(w.opts.Include, entryRel) {
+				w.result.Excluded++
+				continue
+			}
+			info, err := os.Stat(localPath)
+			if err != nil {
+				return err
+			}
+			w.result.Files = append(w.result.Files, LocalFile{Path: localPath, Rel: entryRel, Size: info.Size(), Info: info})
+		}
+	}
+	return nil
+}
+
+// excluded 文件或目录是否被排除
+func (w *walker) excluded(rel string) bool {
+	return matchAny(w.opts.Exclude, rel)
+}
+
+// Selected 相对路径的文件是否会被遍历选中（只看 include/exclude，不检查文件是否存在）
+// 所在的任意一级目录被排除时，文件也不会被选中
+func (o WalkOptions) Selected(rel string) bool {
+	parts := strings.Split(rel, "/")
+	for i := range parts {
+		if matchAny(o.Exclude, strings.Join(parts[:i+1], "/")) {
+			return false
+		}
+	}
+	return len(o.Include) == 0 || matchAny(o.Include, rel)
+}
+
+func matchAny(patterns []string, rel string) bool {
+	for _, p := range patterns {
+		if MatchGlob(p, rel) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,217 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		rel     string
+		want    bool
+	}{
+		{"*.tmp", "a.tmp", true},
+		{"*.tmp", "dir/sub/a.tmp", true},
+		{"*.tmp", "a.txt", false},
+		{"node_modules", "web/node_modules", true},
+		{"node_modules", "web/node_modules/x.js", true},
+		{"logs/*.log", "logs/a.log", true},
+		{"logs/*.log", "logs/old/a.log", false},
+		{"logs/*.log", "app/logs/a.log", false},
+		{"/logs/*.log", "logs/a.log", true},
+		{"logs/**/*.log", "logs/a.log", true},
+		{"logs/**/*.log", "logs/2024/01/a.log", true},
+		{"**/cache", "a/b/cache", true},
+		{"**/cache", "cache", true},
+		{"build/**", "build/out/x.bin", true},
+		{"build/**", "src/build/x.bin", false},
+		{"a/?.txt", "a/b.txt", true},
+		{"a/[0-9].txt", "a/x.txt", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.rel, func(t *testing.T) {
+			if got := MatchGlob(tt.pattern, tt.rel); got != tt.want {
+				t.Errorf("MatchGlob(%q, %q) = %v，应为 %v", tt.pattern, tt.rel, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWalkOptionsSelected(t *testing.T) {
+	tests := []struct {
+		name string
+		opts WalkOptions
+		rel  string
+		want bool
+	}{
+		{"没有过滤条件", WalkOptions{}, "a/b.txt", true},
+		{"匹配 include", WalkOptions{Include: []string{"*.txt"}}, "a/b.txt", true},
+		{"不匹配 include", WalkOptions{Include: []string{"*.txt"}}, "a/b.log", false},
+		{"exclude 文件", WalkOptions{Exclude: []string{"*.tmp"}}, "a/b.tmp", false},
+		{"exclude 上级目录", WalkOptions{Exclude: []string{"cache"}}, "x/cache/y/z.txt", false},
+		{"exclude 含 / 的目录", WalkOptions{Exclude: []string{"x/cache"}}, "x/cache/z.txt", false},
+		{"exclude 优先于 include", WalkOptions{Include: []string{"*.txt"}, Exclude: []string{"secret"}}, "secret/a.txt", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.opts.Selected(tt.rel); got != tt.want {
+				t.Errorf("Selected(%q) = %v，应为 %v", tt.rel, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSymlinkPolicy(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    SymlinkPolicy
+		wantErr bool
+	}{
+		{"", SymlinkSkip, false},
+		{"skip", SymlinkSkip, false},
+		{"Follow", SymlinkFollow, false},
+		{" error ", SymlinkError, false},
+		{"ignore", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseSymlinkPolicy(tt.value)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("ParseSymlinkPolicy(%q) = %q, %v，应为 %q（错误: %v）", tt.value, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+// makeWalkTree 创建包含文件、目录和各种符号链接的测试目录，返回根目录
+func makeWalkTree(t *testing.T) string {
+	t.Helper()
+	base := t.TempDir()
+	root := filepath.Join(base, "root")
+	outside := filepath.Join(base, "outside")
+
+	for _, f := range []string{
+		"root/a.txt",
+		"root/b.tmp",
+		"root/sub/c.txt",
+		"root/sub/deep/d.log",
+		"root/node_modules/x.js",
+		"outside/e.txt",
+	} {
+		p := filepath.Join(base, filepath.FromSlash(f))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(f), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, target := range map[string]string{
+		"link-file": "a.txt",
+		"link-dir":  "sub",
+		"loop":      ".",
+		"outside":   outside,
+	} {
+		if err := os.Symlink(target, filepath.Join(root, name)); err != nil {
+			t.Skipf("无法创建符号链接: %v", err)
+		}
+	}
+	return root
+}
+
+func TestWalkFiles(t *testing.T) {
+	tests := []struct {
+		name         string
+		opts         WalkOptions
+		wantFiles    []string
+		wantSymlinks []string
+		wantExcluded int
+		wantErr      bool
+	}{
+		{
+			name:         "skip 策略跳过并记录符号链接",
+			opts:         WalkOptions{Symlinks: SymlinkSkip},
+			wantFiles:    []string{"a.txt", "b.tmp", "node_modules/x.js", "sub/c.txt", "sub/deep/d.log"},
+			wantSymlinks: []string{"link-dir", "link-file", "loop", "outside"},
+		},
+		{
+			name: "follow 策略按链接路径展开，指回上级的链接不循环",
+			opts: WalkOptions{Symlinks: SymlinkFollow},
+			wantFiles: []string{
+				"a.txt", "b.tmp",
+				"link-dir/c.txt", "link-dir/deep/d.log",
+				"link-file",
+				"node_modules/x.js",
+				"outside/e.txt",
+				"sub/c.txt", "sub/deep/d.log",
+			},
+		},
+		{
+			name:    "error 策略遇到符号链接报错",
+			opts:    WalkOptions{Symlinks: SymlinkError},
+			wantErr: true,
+		},
+		{
+			name:         "include 和 exclude",
+			opts:         WalkOptions{Include: []string{"*.txt"}, Exclude: []string{"node_modules", "*.tmp"}},
+			wantFiles:    []string{"a.txt", "sub/c.txt"},
+			wantSymlinks: []string{"link-dir", "link-file", "loop", "outside"},
+			wantExcluded: 2,
+		},
+		{
+			name:         "被排除的符号链接不触发 error 策略",
+			opts:         WalkOptions{Symlinks: SymlinkError, Exclude: []string{"link-*", "loop", "outside"}},
+			wantFiles:    []string{"a.txt", "b.tmp", "node_modules/x.js", "sub/c.txt", "sub/deep/d.log"},
+			wantExcluded: 4,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := makeWalkTree(t)
+			result, err := WalkFiles(root, tt.opts)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("应返回错误")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("WalkFiles 返回错误: %v", err)
+			}
+
+			var files []string
+			for _, f := range result.Files {
+				files = append(files, f.Rel)
+				if f.Size != f.Info.Size() || f.Info.IsDir() {
+					t.Errorf("%s 的文件信息不正确", f.Rel)
+				}
+			}
+			if !reflect.DeepEqual(files, tt.wantFiles) {
+				t.Errorf("文件 = %v，应为 %v", files, tt.wantFiles)
+			}
+			if !reflect.DeepEqual(result.Symlinks, tt.wantSymlinks) {
+				t.Errorf("跳过的符号链接 = %v，应为 %v", result.Symlinks, tt.wantSymlinks)
+			}
+			if result.Excluded != tt.wantExcluded {
+				t.Errorf("过滤掉 %d 个文件，应为 %d", result.Excluded, tt.wantExcluded)
+			}
+		})
+	}
+}
+
+func TestWalkFilesBrokenSymlink(t *testing.T) {
+	root := t.TempDir()
+	if err := os.Symlink("missing", filepath.Join(root, "broken")); err != nil {
+		t.Skipf("无法创建符号链接: %v", err)
+	}
+	if _, err := WalkFiles(root, WalkOptions{Symlinks: SymlinkFollow}); err == nil {
+		t.Error("follow 策略遇到无效的符号链接应返回错误")
+	}
+	result, err := WalkFiles(root, WalkOptions{})
+	if err != nil || !reflect.DeepEqual(result.Symlinks, []string{"broken"}) {
+		t.Errorf("skip 策略应记录无效的符号链接，得到 %v, %v", result, err)
+	}
+}